@@ -0,0 +1,80 @@
+package metodos
+
+import (
+	"context"
+	"sync"
+)
+
+// loteParalelo é o número de nós entregue de cada vez a um trabalhador;
+// lotes menores não compensam o custo de sincronização.
+const loteParalelo = 64
+
+// avaliadorNos avalia o integrando em cada um dos nós, guardando o resultado
+// na mesma posição de valores.
+type avaliadorNos func(ctx context.Context, nos, valores []float64) error
+
+func avaliacaoSequencial(f func(float64) (float64, error)) avaliadorNos {
+	return func(ctx context.Context, nos, valores []float64) error {
+		for i, x := range nos {
+			fx, err := f(x)
+			if err != nil {
+				return err
+			}
+			valores[i] = fx
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// avaliacaoParalela distribui os nós em lotes entre as funções, cada uma
+// usada por uma única goroutine. O primeiro erro cancela os demais
+// trabalhadores. Poucos nós são avaliados sem paralelismo.
+func avaliacaoParalela(fs []func(float64) (float64, error)) avaliadorNos {
+	return func(ctx context.Context, nos, valores []float64) error {
+		if len(fs) == 1 || len(nos) < 2*loteParalelo {
+			return avaliacaoSequencial(fs[0])(ctx, nos, valores)
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		lotes := make(chan int)
+		erros := make(chan error, len(fs))
+		var wg sync.WaitGroup
+		for _, f := range fs {
+			wg.Add(1)
+			go func(avaliar avaliadorNos) {
+				defer wg.Done()
+				for inicio := range lotes {
+					fim := min(inicio+loteParalelo, len(nos))
+					if err := avaliar(ctx, nos[inicio:fim], valores[inicio:fim]); err != nil {
+						erros <- err
+						cancel()
+						return
+					}
+				}
+			}(avaliacaoSequencial(f))
+		}
+	enviar:
+		for inicio := 0; inicio < len(nos); inicio += loteParalelo {
+			select {
+			case lotes <- inicio:
+			case <-ctx.Done():
+				break enviar
+			}
+		}
+		close(lotes)
+		wg.Wait()
+
+		// o erro de quem cancelou chega antes dos erros de cancelamento; sem
+		// erro de trabalhador, um contexto encerrado deixou nós sem avaliar
+		select {
+		case err := <-erros:
+			return err
+		default:
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package metodos
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// maxNivelClenshawCurtis limita a malha a 2^12 + 1 pontos de Chebyshev.
+const maxNivelClenshawCurtis = 12
+
+// ClenshawCurtis integra interpolando f nos pontos de Chebyshev
+// x_j = cos(jπ/N) e integrando o polinômio exatamente, o que dá convergência
+// espectral para integrandos suaves. N dobra a cada refinamento e os pontos
+// antigos continuam na malha, então cada avaliação é feita uma só vez.
+//
+// O erro é estimado pelo decaimento dos coeficientes de Chebyshev: o maior
+// dos últimos coeficientes mede o que a interpolação ainda não captura.
+func ClenshawCurtis(integral Expressao, criterio CriterioParada) (ResultadoIntegral, error) {
+	if err := criterio.validarIntegracao(); err != nil {
+		return ResultadoIntegral{}, err
+	}
+	if err := integral.limitesFinitos(); err != nil {
+		return ResultadoIntegral{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	expr, err := NewExpressaoAvaliavel(integral)
+	if err != nil {
+		return ResultadoIntegral{}, err
+	}
+
+	quebras := expr.pontosDeQuebra(integral.A, integral.B)
+	resultado, err := porPartes(quebras, integral.A, integral.B, criterio, func(t trecho, criterio CriterioParada) (ResultadoIntegral, error) {
+		return clenshawCurtis(ctx, t.interior(expr.avaliarEm), t.a, t.b, criterio)
+	})
+	resultado.Avaliacoes = expr.Avaliacoes()
+	return resultado, err
+}
+
+func clenshawCurtis(ctx context.Context, f func(float64) (float64, error), a, b float64, criterio CriterioParada) (ResultadoIntegral, error) {
+	var resultado ResultadoIntegral
+	centro, meio := (a+b)/2, (b-a)/2
+
+	var valores []float64
+	for nivel, n := 1, 4; ; nivel, n = nivel+1, n*2 {
+		// os pontos de índice par são os da malha anterior
+		novos := make([]float64, n+1)
+		for j := range novos {
+			if valores != nil && j%2 == 0 {
+				novos[j] = valores[j/2]
+				continue
+			}
+			x := centro + meio*math.Cos(float64(j)*math.Pi/float64(n))
+			var fx float64
+			var err error
+			if j == 0 || j == n {
+				fx, err = avaliarExtremo(f, x)
+			} else {
+				fx, err = f(x)
+			}
+			if err != nil {
+				return resultado, err
+			}
+			resultado.Avaliacoes++
+			novos[j] = fx
+		}
+		valores = novos
+
+		coeficientes := coeficientesChebyshev(valores)
+		valor, escala := 0.0, 0.0
+		for k, c := range coeficientes {
+			if k == 0 || k == n {
+				c /= 2
+			}
+			escala += math.Abs(c)
+			if k%2 == 0 {
+				valor += c * 2 / float64(1-k*k)
+			}
+		}
+		// os últimos quatro coeficientes, porque integrandos pares ou ímpares
+		// zeram os coeficientes alternados
+		cauda := 0.0
+		for k := n - 3; k <= n; k++ {
+			cauda = math.Max(cauda, math.Abs(coeficientes[k]))
+		}
+		erro := math.Max(cauda, 50*epsilonMaquina*escala) * math.Abs(meio)
+		valor *= meio
+
+		resultado.Valor, resultado.ErroEstimado = valor, erro
+		estado := estadoIteracao{nivel, resultado.Avaliacoes, valor, valor - erro, math.NaN()}
+		if criterio.convergiu(estado) {
+			resultado.Convergiu = true
+			return resultado, nil
+		}
+		if motivo := criterio.esgotado(estado); motivo != "" {
+			resultado.Avisos = append(resultado.Avisos, motivo)
+			return resultado, nil
+		}
+		if nivel == maxNivelClenshawCurtis {
+			resultado.Avisos = append(resultado.Avisos, "limite de pontos de Clenshaw-Curtis atingido sem convergência; o integrando pode não ser suave")
+			return resultado, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			resultado.Avisos = append(resultado.Avisos, "tempo esgotado antes da convergência")
+			return resultado, nil
+		default:
+			continue
+		}
+	}
+}
+
+// coeficientesChebyshev calcula os coeficientes c_k do interpolante
+// Σ c_k T_k dos valores nos pontos cos(jπ/N), em que o primeiro e o último
+// termos entram pela metade. É a transformada discreta de cossenos do tipo I
+// somada diretamente, em O(N²), o que basta até a malha máxima de 2^12 + 1
+// pontos.
+func coeficientesChebyshev(valores []float64) []float64 {
+	n := len(valores) - 1
+	coeficientes := make([]float64, n+1)
+	for k := range coeficientes {
+		soma := (valores[0] + valores[n]*math.Cos(float64(k)*math.Pi)) / 2
+		for j := 1; j < n; j++ {
+			// reduz jk módulo 2N para manter o argumento do cosseno pequeno
+			soma += valores[j] * math.Cos(float64(j*k%(2*n))*math.Pi/float64(n))
+		}
+		coeficientes[k] = 2 * soma / float64(n)
+	}
+	return coeficientes
+}
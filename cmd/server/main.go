@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
 	"strconv"
 	"time"
 	"github.com/fuzzyqu/metodos"
@@ -18,14 +19,30 @@ func main() {
 
 	router.Static("/", "view/")
 
+	router.POST("/validar", validar)
+	router.POST("/gausskronrod/:erro", gausskronrod)
+	router.POST("/tanhsinh/:erro", tanhsinh)
+	router.POST("/integralmultipla/:erro", integralmultipla)
+	router.POST("/montecarlo", montecarlo)
+	router.POST("/clenshawcurtis/:erro", clenshawcurtis)
 	router.POST("/simpson38/:erro", simpson38)
 	router.POST("/simpson13/:erro", simpson13)
 	router.POST("/trapezio/:erro", trapezio)
 	router.POST("/newtoncotes4/:erro", newtoncotes4)
+	router.POST("/newtoncotes/:erro", newtoncotes)
 	router.POST("/bissecao/:erro", bissecao)
 	router.POST("/posicaofalsa/:erro", posicaofalsa)
 	router.POST("/newtonraphson/:erro", newtonraphson)
 	router.POST("/secante/:erro", secante)
+	router.POST("/newtonraphsonmultiplo/:erro", newtonraphsonmultiplo)
+	router.POST("/secantemultipla/:erro", secantemultipla)
+	router.POST("/halley/:erro", halley)
+	router.POST("/newtonmultiplicidade/:erro", newtonmultiplicidade)
+	router.POST("/newtonmodificado/:erro", newtonmodificado)
+	router.POST("/muller/:erro", muller)
+	router.POST("/ridders/:erro", ridders)
+	router.POST("/raizes/:erro", raizes)
+	router.POST("/pontofixo/:erro", pontofixo)
 
 	srv := &http.Server{
 		Addr:         ":8080",
@@ -40,7 +57,7 @@ func main() {
 		}
 	}()
 
-	exit := make(chan os.Signal)
+	exit := make(chan os.Signal, 1)
 	signal.Notify(exit, os.Interrupt)
 	<-exit
 	log.Println("Desligando o servidor ...")
@@ -52,120 +69,428 @@ func main() {
 	log.Println("Servidor desligado.")
 }
 
+// responderErro devolve o erro em JSON; erros de expressão levam também a
+// posição do trecho inválido, para que a interface possa destacá-lo, e erros
+// de domínio levam a função e o ponto em que a expressão não está definida.
+func responderErro(c *gin.Context, err error) {
+	resposta := gin.H{"error": err.Error()}
+	switch erro := errors.Cause(err).(type) {
+	case *metodos.ErroExpressao:
+		resposta["expressao"] = erro
+	case *metodos.ErroDominio:
+		resposta["dominio"] = erro
+	}
+	c.JSON(http.StatusInternalServerError, resposta)
+}
+
+func validar(c *gin.Context) {
+	expr, err := extractJSON(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	avisos, err := metodos.ValidarExpressao(expr)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	if avisos == nil {
+		avisos = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"avisos": avisos})
+}
+
+func gausskronrod(c *gin.Context) {
+	expr, criterio, err := parseInput(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	result, err := metodos.GaussKronrod(expr, criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func tanhsinh(c *gin.Context) {
+	expr, criterio, err := parseInput(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	result, err := metodos.TanhSinh(expr, criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func integralmultipla(c *gin.Context) {
+	criterio, err := extractCriterio(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	var integral metodos.IntegralMultipla
+	if err := c.ShouldBindJSON(&integral); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrap(err, "erro ao ler o json").Error()})
+		return
+	}
+	result, err := metodos.IntegrarMultipla(integral, metodos.RegraMultipla(c.Query("regra")), criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func montecarlo(c *gin.Context) {
+	var integral metodos.IntegralMultipla
+	if err := c.ShouldBindJSON(&integral); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrap(err, "erro ao ler o json").Error()})
+		return
+	}
+	opcoes := metodos.OpcoesMonteCarlo{Amostragem: metodos.AmostragemMonteCarlo(c.Query("amostragem"))}
+	for nome, destino := range map[string]*int{"amostras": &opcoes.Amostras, "trabalhadores": &opcoes.Trabalhadores} {
+		if t := c.Query(nome); t != "" {
+			v, err := strconv.Atoi(t)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrapf(err, "valor de %s inválido", nome).Error()})
+				return
+			}
+			*destino = v
+		}
+	}
+	opcoes.Trabalhadores = limitarTrabalhadores(opcoes.Trabalhadores)
+	if t := c.Query("semente"); t != "" {
+		semente, err := strconv.ParseInt(t, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrap(err, "valor de semente inválido").Error()})
+			return
+		}
+		opcoes.Semente = semente
+	}
+	result, err := metodos.MonteCarlo(integral, opcoes)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func newtoncotes(c *gin.Context) {
+	expr, criterio, err := parseInput(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	ordem, err := strconv.Atoi(c.DefaultQuery("ordem", "4"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrap(err, "valor de ordem inválido").Error()})
+		return
+	}
+	trabalhadores, err := strconv.Atoi(c.DefaultQuery("trabalhadores", "1"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrap(err, "valor de trabalhadores inválido").Error()})
+		return
+	}
+	formula := metodos.FormulaNewtonCotes(c.Query("formula"))
+	result, err := metodos.NewtonCotesParalela(expr, ordem, formula, criterio, limitarTrabalhadores(trabalhadores))
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func clenshawcurtis(c *gin.Context) {
+	expr, criterio, err := parseInput(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	result, err := metodos.ClenshawCurtis(expr, criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 func simpson38(c *gin.Context) {
-	expr, erro, err := parseInput(c)
+	expr, criterio, err := parseInput(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
+		return
 	}
-	result, err := metodos.RegraDeSimpson38Repetida(expr, erro)
+	result, err := metodos.RegraDeSimpson38Repetida(expr, criterio)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, result)
 }
 
 func simpson13(c *gin.Context) {
-	expr, erro, err := parseInput(c)
+	expr, criterio, err := parseInput(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
+		return
 	}
-	result, err := metodos.RegraDeSimpson13Repetida(expr, erro)
+	result, err := metodos.RegraDeSimpson13Repetida(expr, criterio)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, result)
 }
 
 func newtoncotes4(c *gin.Context) {
-	expr, erro, err := parseInput(c)
+	expr, criterio, err := parseInput(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
+		return
 	}
-	result, err := metodos.RegraNewtonCotes4(expr, erro)
+	result, err := metodos.RegraNewtonCotes4(expr, criterio)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, result)
 }
 
 func trapezio(c *gin.Context) {
-	expr, erro, err := parseInput(c)
+	expr, criterio, err := parseInput(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
+		return
 	}
-	result, err := metodos.RegraDosTrapeziosRepetida(expr, erro)
+	result, err := metodos.RegraDosTrapeziosRepetida(expr, criterio)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, result)
 }
 
 func bissecao(c *gin.Context) {
-	expr, erro, err := parseInput(c)
+	expr, criterio, err := parseInput(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
+		return
 	}
-	result, err := metodos.Bisseccao(expr, erro)
+	result, err := metodos.Bisseccao(expr, criterio)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, result)
 }
 
 func posicaofalsa(c *gin.Context) {
-	expr, erro, err := parseInput(c)
+	expr, criterio, err := parseInput(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
+		return
 	}
-	result, err := metodos.PosicaoFalsa(expr, erro)
+	variante := metodos.VariantePosicaoFalsa(c.Query("variante"))
+	result, err := metodos.PosicaoFalsa(expr, variante, criterio)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, result)
 }
 
 func newtonraphson(c *gin.Context) {
-	expr, derivada, erro, err := parseNewtonRaphson(c)
+	entrada, derivada, criterio, err := parseNewtonRaphson(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
+		return
 	}
-	result, err := metodos.NewtonRalphson(expr, derivada, erro)
+	result, err := metodos.NewtonRalphson(entrada.Expressao, derivada, entrada.chute(), criterio)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"result": result})
+	c.JSON(http.StatusOK, result)
 }
 
 func secante(c *gin.Context) {
-	expr, erro, err := parseInput(c)
+	entrada, criterio, err := parseChutes(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	x0, x1 := entrada.chutes()
+	result, err := metodos.Secante(entrada.Expressao, x0, x1, criterio)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func newtonraphsonmultiplo(c *gin.Context) {
+	entrada, derivada, criterio, err := parseNewtonRaphson(c)
+	if err != nil {
+		responderErro(c, err)
+		return
 	}
-	result, err := metodos.Secante(expr, erro)
+	chutes, err := extractChutes(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		responderErro(c, err)
+		return
+	}
+	result, err := metodos.NewtonRalphsonMultiplo(entrada.Expressao, derivada, chutes, criterio)
+	if err != nil {
+		responderErro(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"result": result})
 }
 
-func parseInput(c *gin.Context) (metodos.Expressao, int, error) {
-	erro, err := extractError(c)
+func secantemultipla(c *gin.Context) {
+	expr, criterio, err := parseInput(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	chutes, err := extractChutes(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	result, err := metodos.SecanteMultipla(expr, chutes, criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": result})
+}
+
+func halley(c *gin.Context) {
+	entrada, derivada, segundaDerivada, criterio, err := parseHalley(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	result, err := metodos.Halley(entrada.Expressao, derivada, segundaDerivada, entrada.chute(), criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func newtonmultiplicidade(c *gin.Context) {
+	entrada, derivada, criterio, err := parseNewtonRaphson(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	m, err := strconv.Atoi(c.Query("m"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "é necessário passar a multiplicidade m da raiz"})
+		return
+	}
+	result, err := metodos.NewtonMultiplicidade(entrada.Expressao, derivada, m, entrada.chute(), criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func newtonmodificado(c *gin.Context) {
+	entrada, derivada, segundaDerivada, criterio, err := parseHalley(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	result, err := metodos.NewtonModificado(entrada.Expressao, derivada, segundaDerivada, entrada.chute(), criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func muller(c *gin.Context) {
+	expr, criterio, err := parseInput(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	result, err := metodos.Muller(expr, criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func ridders(c *gin.Context) {
+	expr, criterio, err := parseInput(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	result, err := metodos.Ridders(expr, criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func raizes(c *gin.Context) {
+	expr, criterio, err := parseInput(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	amostras, err := strconv.Atoi(c.DefaultQuery("amostras", "100"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": errors.Wrap(err, "número de amostras inválido").Error()})
+		return
+	}
+	result, err := metodos.BuscarRaizes(expr, c.DefaultQuery("metodo", "bissecao"), amostras, criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func pontofixo(c *gin.Context) {
+	entrada, criterio, err := parseChutes(c)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	aceleracao := metodos.Aceleracao(c.Query("aceleracao"))
+	result, err := metodos.PontoFixo(entrada.Expressao, entrada.chute(), aceleracao, criterio)
+	if err != nil {
+		responderErro(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func parseInput(c *gin.Context) (metodos.Expressao, metodos.CriterioParada, error) {
+	criterio, err := extractCriterio(c)
 	if err != nil {
-		return metodos.Expressao{}, 0, err
+		return metodos.Expressao{}, metodos.CriterioParada{}, err
 	}
 	expr, err := extractJSON(c)
 	if err != nil {
-		return metodos.Expressao{}, 0, err
+		return metodos.Expressao{}, metodos.CriterioParada{}, err
 	}
-	return expr, erro, nil
+	return expr, criterio, nil
 }
 
 func extractJSON(c *gin.Context) (metodos.Expressao, error) {
@@ -186,15 +511,139 @@ func extractError(c *gin.Context) (int, error) {
 	return erro, nil
 }
 
-func parseNewtonRaphson(c *gin.Context) (metodos.Expressao, metodos.Expressao, int, error) {
-	expr, erro, err := parseInput(c)
+// extractCriterio monta o critério de parada a partir do erro da rota. As
+// tolerâncias passoabs, passorel e residuo, quando alguma é informada na query,
+// substituem as tolerâncias padrão; maxiter, maxaval e combinacao são opcionais.
+func extractCriterio(c *gin.Context) (metodos.CriterioParada, error) {
+	erro, err := extractError(c)
+	if err != nil {
+		return metodos.CriterioParada{}, err
+	}
+	criterio := metodos.NewCriterioParada(erro)
+
+	tolerancias := []struct {
+		nome  string
+		valor *float64
+	}{
+		{"passoabs", &criterio.PassoAbsoluto},
+		{"passorel", &criterio.PassoRelativo},
+		{"residuo", &criterio.Residuo},
+	}
+	informadas := false
+	for _, t := range tolerancias {
+		v, ok := c.GetQuery(t.nome)
+		if !ok {
+			continue
+		}
+		if !informadas {
+			criterio.PassoAbsoluto, criterio.PassoRelativo, criterio.Residuo = 0, 0, 0
+			informadas = true
+		}
+		if *t.valor, err = strconv.ParseFloat(v, 64); err != nil {
+			return metodos.CriterioParada{}, errors.Wrapf(err, "valor de %s inválido", t.nome)
+		}
+	}
+
+	limites := []struct {
+		nome  string
+		valor *int
+	}{
+		{"maxiter", &criterio.MaxIteracoes},
+		{"maxaval", &criterio.MaxAvaliacoes},
+	}
+	for _, l := range limites {
+		if v, ok := c.GetQuery(l.nome); ok {
+			if *l.valor, err = strconv.Atoi(v); err != nil {
+				return metodos.CriterioParada{}, errors.Wrapf(err, "valor de %s inválido", l.nome)
+			}
+		}
+	}
+
+	if combinacao, ok := c.GetQuery("combinacao"); ok {
+		criterio.Combinacao = metodos.Combinacao(combinacao)
+	}
+	return criterio, nil
+}
+
+func parseNewtonRaphson(c *gin.Context) (entradaChutes, metodos.Expressao, metodos.CriterioParada, error) {
+	entrada, criterio, err := parseChutes(c)
 	if err != nil {
-		return metodos.Expressao{}, metodos.Expressao{}, 0, err
+		return entradaChutes{}, metodos.Expressao{}, metodos.CriterioParada{}, err
 	}
 	derivada := c.Query("derivada")
 	if derivada == "" {
-		return metodos.Expressao{}, metodos.Expressao{}, 0, errors.New("é necessário passar a derivada de f(x)")
+		return entradaChutes{}, metodos.Expressao{}, metodos.CriterioParada{}, errors.New("é necessário passar a derivada de f(x)")
 	}
 	derivadaExpr := metodos.Expressao{Corpo: derivada}
-	return expr, derivadaExpr, erro, nil
+	return entrada, derivadaExpr, criterio, nil
+}
+
+func parseHalley(c *gin.Context) (entradaChutes, metodos.Expressao, metodos.Expressao, metodos.CriterioParada, error) {
+	entrada, derivada, criterio, err := parseNewtonRaphson(c)
+	if err != nil {
+		return entradaChutes{}, metodos.Expressao{}, metodos.Expressao{}, metodos.CriterioParada{}, err
+	}
+	segundaDerivada := c.Query("derivada2")
+	if segundaDerivada == "" {
+		return entradaChutes{}, metodos.Expressao{}, metodos.Expressao{}, metodos.CriterioParada{}, errors.New("é necessário passar a segunda derivada de f(x)")
+	}
+	segundaDerivadaExpr := metodos.Expressao{Corpo: segundaDerivada}
+	return entrada, derivada, segundaDerivadaExpr, criterio, nil
+}
+
+// entradaChutes é a expressão acompanhada dos pontos de partida opcionais dos
+// métodos abertos.
+type entradaChutes struct {
+	metodos.Expressao
+	X0 *float64 `json:"x0,string"`
+	X1 *float64 `json:"x1,string"`
+}
+
+// chute devolve x0 ou, na sua ausência, o ponto de partida padrão de [A, B].
+func (e entradaChutes) chute() float64 {
+	if e.X0 != nil {
+		return *e.X0
+	}
+	return metodos.ChuteInicial(e.Expressao)
+}
+
+// chutes devolve x0 e x1 ou, na ausência deles, os pontos padrão da secante.
+func (e entradaChutes) chutes() (float64, float64) {
+	x0, x1 := metodos.ChutesIniciais(e.Expressao)
+	if e.X0 != nil {
+		x0 = *e.X0
+	}
+	if e.X1 != nil {
+		x1 = *e.X1
+	}
+	return x0, x1
+}
+
+func parseChutes(c *gin.Context) (entradaChutes, metodos.CriterioParada, error) {
+	criterio, err := extractCriterio(c)
+	if err != nil {
+		return entradaChutes{}, metodos.CriterioParada{}, err
+	}
+	var entrada entradaChutes
+	if err := c.ShouldBindJSON(&entrada); err != nil {
+		return entradaChutes{}, metodos.CriterioParada{}, errors.Wrap(err, "erro ao ler o json")
+	}
+	return entrada, criterio, nil
+}
+
+func extractChutes(c *gin.Context) (int, error) {
+	chutes, err := strconv.Atoi(c.DefaultQuery("chutes", "10"))
+	if err != nil {
+		return 0, errors.Wrap(err, "número de chutes inválido")
+	}
+	return chutes, nil
+}
+
+// limitarTrabalhadores reduz o número de goroutines pedido na query ao número
+// de CPUs, para que uma requisição não consiga criar goroutines à vontade.
+func limitarTrabalhadores(n int) int {
+	if n > runtime.NumCPU() {
+		return runtime.NumCPU()
+	}
+	return n
 }
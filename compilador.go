@@ -0,0 +1,714 @@
+package metodos
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// As expressões são analisadas uma única vez para uma árvore sintática, que
+// é compilada para o código de pilha das avaliações reais ou para as funções
+// das avaliações complexas. A sintaxe é a que o govaluate aceitava: números
+// decimais, + - * / % **, parênteses, as constantes e e pi e as funções de
+// funcoesMatematicas, com os argumentos separados por vírgulas. O menos
+// unário liga mais forte que ** e todos os operadores binários associam à
+// esquerda, então -x**2 é (-x)**2 e 2**3**2 é 64.
+//
+// Além disso, as comparações < <= > >= == != valem 1 ou 0 e têm a menor
+// precedência, e há duas formas condicionais: if(c, a, b) vale a se c não é
+// zero e b se é, e piecewise(c1, v1, c2, v2, ..., padrao) vale o v da primeira
+// condição verdadeira, ou padrao, que é opcional: sem ele, a expressão não
+// está definida onde nenhuma condição vale. Só o ramo escolhido é avaliado.
+
+// no é um nó da árvore sintática.
+type no interface{}
+
+type (
+	numero struct {
+		valor float64
+	}
+	variavel struct {
+		nome   string
+		coluna int
+	}
+	negacao struct {
+		operando no
+	}
+	binario struct {
+		op                byte
+		esquerda, direita no
+	}
+	chamada struct {
+		funcao     int
+		argumentos []no
+	}
+	// chamadaDefinida chama uma das definições do usuário; só existe até a
+	// expansão das definições
+	chamadaDefinida struct {
+		nome       string
+		coluna     int
+		argumentos []no
+	}
+	// condicional vale entao se condicao não é zero; senao nil deixa a
+	// expressão indefinida nesse caso
+	condicional struct {
+		condicao, entao, senao no
+	}
+)
+
+// operadores binários representados por outro byte
+const (
+	operadorPotencia   = '^'
+	operadorMenorIgual = 'm'
+	operadorMaiorIgual = 'M'
+	operadorIgual      = '='
+	operadorDiferente  = '!'
+)
+
+var operadoresComparacao = map[string]byte{
+	"<":  '<',
+	"<=": operadorMenorIgual,
+	">":  '>',
+	">=": operadorMaiorIgual,
+	"==": operadorIgual,
+	"!=": operadorDiferente,
+}
+
+// formasCondicionais são analisadas à parte, pois só avaliam um ramo.
+var formasCondicionais = []string{"if", "piecewise"}
+
+// constantes são nomes que nunca são tratados como variáveis.
+var constantes = map[string]float64{
+	"e":  math.E,
+	"pi": math.Pi,
+}
+
+// analisar constrói a árvore sintática de corpo, que pode chamar as
+// definições do usuário.
+func analisar(corpo string, definicoes map[string]*definicao) (no, error) {
+	p := analisador{texto: []rune(corpo), definicoes: definicoes}
+	p.avancar()
+	raiz, err := p.comparacao()
+	if err != nil {
+		return nil, err
+	}
+	if p.token != "" {
+		return nil, p.erro(fmt.Sprintf("símbolo inesperado %q", p.token))
+	}
+	return raiz, nil
+}
+
+type analisador struct {
+	texto []rune
+	pos   int
+	// inicio é a posição do token atual em texto
+	inicio int
+	token  string
+	numero bool
+	// definicoes são as funções definidas pelo usuário que podem ser chamadas
+	definicoes map[string]*definicao
+}
+
+// erro aponta para o token atual.
+func (p *analisador) erro(mensagem string) *ErroExpressao {
+	return &ErroExpressao{Mensagem: mensagem, Coluna: p.inicio + 1, Comprimento: len([]rune(p.token))}
+}
+
+func (p *analisador) avancar() {
+	for p.pos < len(p.texto) && unicode.IsSpace(p.texto[p.pos]) {
+		p.pos++
+	}
+	p.numero = false
+	p.inicio = p.pos
+	if p.pos >= len(p.texto) {
+		p.token = ""
+		return
+	}
+	inicio := p.pos
+	c := p.texto[p.pos]
+	switch {
+	case unicode.IsDigit(c) || c == '.':
+		for p.pos < len(p.texto) && (unicode.IsDigit(p.texto[p.pos]) || p.texto[p.pos] == '.') {
+			p.pos++
+		}
+		p.numero = true
+	case unicode.IsLetter(c) || c == '_':
+		for p.pos < len(p.texto) && (unicode.IsLetter(p.texto[p.pos]) || unicode.IsDigit(p.texto[p.pos]) || p.texto[p.pos] == '_') {
+			p.pos++
+		}
+	case c == '*' && p.pos+1 < len(p.texto) && p.texto[p.pos+1] == '*':
+		p.pos += 2
+	case strings.ContainsRune("<>=!", c) && p.pos+1 < len(p.texto) && p.texto[p.pos+1] == '=':
+		p.pos += 2
+	default:
+		p.pos++
+	}
+	p.token = string(p.texto[inicio:p.pos])
+}
+
+// comparacao é o nível de menor precedência.
+func (p *analisador) comparacao() (no, error) {
+	esquerda, err := p.aditiva()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := operadoresComparacao[p.token]
+		if !ok {
+			return esquerda, nil
+		}
+		p.avancar()
+		direita, err := p.aditiva()
+		if err != nil {
+			return nil, err
+		}
+		esquerda = binario{op, esquerda, direita}
+	}
+}
+
+func (p *analisador) aditiva() (no, error) {
+	esquerda, err := p.multiplicativa()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "+" || p.token == "-" {
+		op := p.token[0]
+		p.avancar()
+		direita, err := p.multiplicativa()
+		if err != nil {
+			return nil, err
+		}
+		esquerda = binario{op, esquerda, direita}
+	}
+	return esquerda, nil
+}
+
+func (p *analisador) multiplicativa() (no, error) {
+	esquerda, err := p.exponencial()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "*" || p.token == "/" || p.token == "%" {
+		op := p.token[0]
+		p.avancar()
+		direita, err := p.exponencial()
+		if err != nil {
+			return nil, err
+		}
+		esquerda = binario{op, esquerda, direita}
+	}
+	return esquerda, nil
+}
+
+func (p *analisador) exponencial() (no, error) {
+	esquerda, err := p.prefixo()
+	if err != nil {
+		return nil, err
+	}
+	for p.token == "**" {
+		p.avancar()
+		direita, err := p.prefixo()
+		if err != nil {
+			return nil, err
+		}
+		esquerda = binario{operadorPotencia, esquerda, direita}
+	}
+	return esquerda, nil
+}
+
+func (p *analisador) prefixo() (no, error) {
+	if p.token == "-" {
+		p.avancar()
+		operando, err := p.prefixo()
+		if err != nil {
+			return nil, err
+		}
+		return negacao{operando}, nil
+	}
+	return p.primario()
+}
+
+func (p *analisador) primario() (no, error) {
+	token := p.token
+	switch {
+	case token == "":
+		return nil, p.erro("fim inesperado da expressão")
+	case p.numero:
+		v, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, p.erro(fmt.Sprintf("número inválido %q", token))
+		}
+		p.avancar()
+		return numero{v}, nil
+	case token == "(":
+		abertura := p.erro("parêntese não fechado")
+		p.avancar()
+		n, err := p.comparacao()
+		if err != nil {
+			return nil, err
+		}
+		if p.token != ")" {
+			if p.token != "" {
+				return nil, p.erro(fmt.Sprintf("símbolo inesperado %q, era esperado \")\"", p.token))
+			}
+			return nil, abertura
+		}
+		p.avancar()
+		return n, nil
+	case token == "_" || unicode.IsLetter([]rune(token)[0]):
+		nome := p.erro("")
+		p.avancar()
+		if p.token == "(" && contemNome(formasCondicionais, token) {
+			return p.condicional(token, nome)
+		}
+		if p.token == "(" {
+			return p.chamada(token, nome)
+		}
+		return variavel{token, nome.Coluna}, nil
+	}
+	return nil, p.erro(fmt.Sprintf("símbolo inesperado %q", token))
+}
+
+// chamada analisa os argumentos da função; nome aponta para o nome dela.
+func (p *analisador) chamada(token string, nome *ErroExpressao) (no, error) {
+	funcao := indiceFuncao(token)
+	definicao, definida := p.definicoes[token]
+	if funcao < 0 && !definida {
+		nome.Mensagem = fmt.Sprintf("função desconhecida %q", token)
+		nome.Sugestao = maisParecido(token, append(nomesFuncoes(), nomesDefinicoes(p.definicoes)...))
+		return nil, nome
+	}
+	var f funcaoMatematica
+	if !definida {
+		f = funcoesMatematicas[funcao]
+	}
+	p.avancar()
+	var argumentos []no
+	// depois de uma vírgula sempre vem outro argumento
+	for p.token != ")" || len(argumentos) > 0 {
+		inicio := p.inicio
+		argumento, err := p.comparacao()
+		if err != nil {
+			return nil, err
+		}
+		argumentos = append(argumentos, argumento)
+		// argumentos constantes já podem ser verificados
+		if c, ok := simplificar(argumento).(numero); ok && !definida && !f.argumentoValido(len(argumentos), c.valor) {
+			exigencia := "um inteiro"
+			if f.naoNegativo {
+				exigencia = "um inteiro não negativo"
+			}
+			trecho := strings.TrimRightFunc(string(p.texto[inicio:p.inicio]), unicode.IsSpace)
+			return nil, &ErroExpressao{
+				Mensagem:    fmt.Sprintf("o argumento %d da função %s precisa ser %s", len(argumentos), token, exigencia),
+				Coluna:      inicio + 1,
+				Comprimento: len([]rune(trecho)),
+			}
+		}
+		if p.token != "," {
+			break
+		}
+		p.avancar()
+	}
+	if err := p.fechar(token); err != nil {
+		return nil, err
+	}
+	if definida {
+		if n := len(definicao.parametros); len(argumentos) != n {
+			nome.Mensagem = fmt.Sprintf("a função %s recebe %s, mas recebeu %d", token, quantosArgumentos(n), len(argumentos))
+			return nil, nome
+		}
+		return chamadaDefinida{token, nome.Coluna, argumentos}, nil
+	}
+	if !f.aceitaArgumentos(len(argumentos)) {
+		nome.Mensagem = fmt.Sprintf("a função %s recebe %s, mas recebeu %d", token, f.aridade(), len(argumentos))
+		return nil, nome
+	}
+	return chamada{funcao, argumentos}, nil
+}
+
+// fechar consome o parêntese que fecha os argumentos da função.
+func (p *analisador) fechar(funcao string) error {
+	if p.token != ")" {
+		if p.token == "" {
+			return p.erro(fmt.Sprintf("parêntese da função %s não fechado", funcao))
+		}
+		return p.erro(fmt.Sprintf("símbolo inesperado %q, era esperado \",\" ou \")\"", p.token))
+	}
+	p.avancar()
+	return nil
+}
+
+// condicional analisa if e piecewise, que viram cadeias de nós condicional:
+// piecewise(c1, v1, c2, v2, padrao) é if(c1, v1, if(c2, v2, padrao)).
+func (p *analisador) condicional(token string, nome *ErroExpressao) (no, error) {
+	p.avancar()
+	var argumentos []no
+	// depois de uma vírgula sempre vem outro argumento
+	for p.token != ")" || len(argumentos) > 0 {
+		argumento, err := p.comparacao()
+		if err != nil {
+			return nil, err
+		}
+		argumentos = append(argumentos, argumento)
+		if p.token != "," {
+			break
+		}
+		p.avancar()
+	}
+	if err := p.fechar(token); err != nil {
+		return nil, err
+	}
+	n := len(argumentos)
+	switch {
+	case token == "if" && n != 3:
+		nome.Mensagem = fmt.Sprintf("a função if recebe 3 argumentos, mas recebeu %d", n)
+		return nil, nome
+	case n < 2:
+		nome.Mensagem = fmt.Sprintf("a função piecewise recebe pelo menos 2 argumentos, mas recebeu %d", n)
+		return nil, nome
+	}
+	var senao no
+	if n%2 == 1 {
+		n--
+		senao = argumentos[n]
+	}
+	for i := n - 2; i >= 0; i -= 2 {
+		senao = condicional{argumentos[i], argumentos[i+1], senao}
+	}
+	return senao, nil
+}
+
+// operadorComparacao diz se op é um operador de comparação.
+func operadorComparacao(op byte) bool {
+	for _, c := range operadoresComparacao {
+		if c == op {
+			return true
+		}
+	}
+	return false
+}
+
+// simboloOperador é o operador como escrito na expressão.
+func simboloOperador(op byte) string {
+	if op == operadorPotencia {
+		return "**"
+	}
+	for simbolo, c := range operadoresComparacao {
+		if c == op {
+			return simbolo
+		}
+	}
+	return string(op)
+}
+
+func booleano(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// operacao aplica um operador binário a números reais.
+func operacao(op byte, a, b float64) float64 {
+	switch op {
+	case '+':
+		return a + b
+	case '-':
+		return a - b
+	case '*':
+		return a * b
+	case '/':
+		return a / b
+	case '%':
+		return math.Mod(a, b)
+	case '<':
+		return booleano(a < b)
+	case '>':
+		return booleano(a > b)
+	case operadorMenorIgual:
+		return booleano(a <= b)
+	case operadorMaiorIgual:
+		return booleano(a >= b)
+	case operadorIgual:
+		return booleano(a == b)
+	case operadorDiferente:
+		return booleano(a != b)
+	}
+	return math.Pow(a, b)
+}
+
+// simplificar avalia as subárvores que não dependem de variáveis. Só serve
+// às avaliações reais: log(-1), por exemplo, é NaN nos reais mas não nos
+// complexos. Subárvores fora do domínio não são simplificadas, para que a
+// avaliação acuse o erro.
+func simplificar(n no) no {
+	switch n := n.(type) {
+	case variavel:
+		if v, ok := constantes[n.nome]; ok {
+			return numero{v}
+		}
+	case negacao:
+		operando := simplificar(n.operando)
+		if c, ok := operando.(numero); ok {
+			return numero{-c.valor}
+		}
+		return negacao{operando}
+	case binario:
+		esquerda, direita := simplificar(n.esquerda), simplificar(n.direita)
+		a, okA := esquerda.(numero)
+		b, okB := direita.(numero)
+		if okA && okB {
+			if v := operacao(n.op, a.valor, b.valor); !math.IsNaN(v) {
+				return numero{v}
+			}
+		}
+		return binario{n.op, esquerda, direita}
+	case chamada:
+		argumentos := make([]no, len(n.argumentos))
+		valores := make([]float64, len(n.argumentos))
+		constante := true
+		for i, a := range n.argumentos {
+			argumentos[i] = simplificar(a)
+			c, ok := argumentos[i].(numero)
+			constante = constante && ok
+			valores[i] = c.valor
+		}
+		if constante {
+			if v := funcoesMatematicas[n.funcao].real(valores); !math.IsNaN(v) {
+				return numero{v}
+			}
+		}
+		return chamada{n.funcao, argumentos}
+	case condicional:
+		condicao := simplificar(n.condicao)
+		if c, ok := condicao.(numero); ok {
+			if c.valor != 0 {
+				return simplificar(n.entao)
+			}
+			if n.senao != nil {
+				return simplificar(n.senao)
+			}
+		}
+		return condicional{condicao, simplificar(n.entao), simplificar(n.senao)}
+	}
+	return n
+}
+
+// substituirVariaveis troca as variáveis com nome em valores pelas
+// subárvores correspondentes, todas de uma vez.
+func substituirVariaveis(n no, valores map[string]no) no {
+	if len(valores) == 0 {
+		return n
+	}
+	switch n := n.(type) {
+	case variavel:
+		if v, ok := valores[n.nome]; ok {
+			return v
+		}
+	case negacao:
+		return negacao{substituirVariaveis(n.operando, valores)}
+	case binario:
+		return binario{n.op, substituirVariaveis(n.esquerda, valores), substituirVariaveis(n.direita, valores)}
+	case chamada:
+		argumentos := make([]no, len(n.argumentos))
+		for i, a := range n.argumentos {
+			argumentos[i] = substituirVariaveis(a, valores)
+		}
+		return chamada{n.funcao, argumentos}
+	case chamadaDefinida:
+		argumentos := make([]no, len(n.argumentos))
+		for i, a := range n.argumentos {
+			argumentos[i] = substituirVariaveis(a, valores)
+		}
+		return chamadaDefinida{n.nome, n.coluna, argumentos}
+	case condicional:
+		return condicional{
+			substituirVariaveis(n.condicao, valores),
+			substituirVariaveis(n.entao, valores),
+			substituirVariaveis(n.senao, valores),
+		}
+	}
+	return n
+}
+
+type codigo uint8
+
+const (
+	empilharConstante codigo = iota
+	empilharVariavel
+	negar
+	aplicarFuncao
+	aplicarOperador
+	// saltarSeFalso desempilha a condição e, se ela for zero, continua na
+	// instrução indice; saltar sempre continua nela
+	saltarSeFalso
+	saltar
+	// indefinido encerra a avaliação com um erro de domínio de piecewise
+	indefinido
+)
+
+type instrucao struct {
+	codigo codigo
+	op     byte
+	indice int
+	// argumentos é o número de argumentos de aplicarFuncao
+	argumentos int
+	valor      float64
+}
+
+// programa é a expressão compilada para uma máquina de pilha. As variáveis
+// ficam em posições fixas, na ordem de variaveis, e a pilha nunca passa de
+// profundidade elementos, então a avaliação não aloca memória.
+type programa struct {
+	instrucoes   []instrucao
+	variaveis    []string
+	profundidade int
+}
+
+func compilar(raiz no) programa {
+	var p programa
+	altura := 0
+	empilhar := func(in instrucao, variacao int) {
+		p.instrucoes = append(p.instrucoes, in)
+		altura += variacao
+		if altura > p.profundidade {
+			p.profundidade = altura
+		}
+	}
+	var emitir func(n no)
+	emitir = func(n no) {
+		switch n := n.(type) {
+		case numero:
+			empilhar(instrucao{codigo: empilharConstante, valor: n.valor}, 1)
+		case variavel:
+			indice := -1
+			for i, nome := range p.variaveis {
+				if nome == n.nome {
+					indice = i
+					break
+				}
+			}
+			if indice < 0 {
+				indice = len(p.variaveis)
+				p.variaveis = append(p.variaveis, n.nome)
+			}
+			empilhar(instrucao{codigo: empilharVariavel, indice: indice}, 1)
+		case negacao:
+			emitir(n.operando)
+			empilhar(instrucao{codigo: negar}, 0)
+		case binario:
+			emitir(n.esquerda)
+			emitir(n.direita)
+			empilhar(instrucao{codigo: aplicarOperador, op: n.op}, -1)
+		case chamada:
+			for _, a := range n.argumentos {
+				emitir(a)
+			}
+			k := len(n.argumentos)
+			empilhar(instrucao{codigo: aplicarFuncao, indice: n.funcao, argumentos: k}, 1-k)
+		case condicional:
+			emitir(n.condicao)
+			seFalso := len(p.instrucoes)
+			empilhar(instrucao{codigo: saltarSeFalso}, -1)
+			emitir(n.entao)
+			// o ramo senão começa com a pilha da altura de antes do então
+			fim := len(p.instrucoes)
+			empilhar(instrucao{codigo: saltar}, -1)
+			p.instrucoes[seFalso].indice = len(p.instrucoes)
+			if n.senao == nil {
+				empilhar(instrucao{codigo: indefinido}, 1)
+			} else {
+				emitir(n.senao)
+			}
+			p.instrucoes[fim].indice = len(p.instrucoes)
+		}
+	}
+	emitir(simplificar(raiz))
+	return p
+}
+
+// nome é o nome da função ou do operador aplicado pela instrução.
+func (in instrucao) nome() string {
+	switch in.codigo {
+	case aplicarFuncao:
+		return funcoesMatematicas[in.indice].nome
+	case indefinido:
+		return "piecewise"
+	}
+	return simboloOperador(in.op)
+}
+
+// executar avalia o programa com os valores das variáveis, usando pilha
+// como área de trabalho. Se uma função der NaN com argumentos que não são
+// NaN, ou um operador sair do seu domínio, a avaliação para e falha é a
+// posição da instrução; do contrário, falha é -1. O NaN de um estouro, como
+// Inf - Inf ou Inf·0, não é erro de domínio e segue na avaliação.
+func (p *programa) executar(variaveis, pilha []float64) (valor float64, falha int) {
+	topo := -1
+	for i := 0; i < len(p.instrucoes); i++ {
+		in := &p.instrucoes[i]
+		switch in.codigo {
+		case empilharConstante:
+			topo++
+			pilha[topo] = in.valor
+		case empilharVariavel:
+			topo++
+			pilha[topo] = variaveis[in.indice]
+		case negar:
+			pilha[topo] = -pilha[topo]
+		case aplicarFuncao:
+			// os argumentos ocupam o topo da pilha e dão lugar ao resultado
+			base := topo - in.argumentos + 1
+			v := funcoesMatematicas[in.indice].real(pilha[base : topo+1])
+			if math.IsNaN(v) && !algumNaN(pilha[base:topo+1]) {
+				return v, i
+			}
+			pilha[base] = v
+			topo = base
+		case aplicarOperador:
+			topo--
+			v := operacao(in.op, pilha[topo], pilha[topo+1])
+			if math.IsNaN(v) && !algumNaN(pilha[topo:topo+2]) && nanDeDominio(in.op, pilha[topo], pilha[topo+1]) {
+				return v, i
+			}
+			pilha[topo] = v
+		case saltarSeFalso:
+			topo--
+			if pilha[topo+1] == 0 {
+				i = in.indice - 1
+			}
+		case saltar:
+			i = in.indice - 1
+		case indefinido:
+			return math.NaN(), i
+		}
+	}
+	return pilha[0], -1
+}
+
+// nanDeDominio diz se o NaN que op deu com a e b, que não são NaN, vem de
+// argumentos fora do domínio do operador, como em 0/0, x % 0 e (-8)**(1/3),
+// e não de um estouro.
+func nanDeDominio(op byte, a, b float64) bool {
+	switch op {
+	case '/':
+		return a == 0 && b == 0
+	case '%':
+		return b == 0
+	case operadorPotencia:
+		return true
+	}
+	return false
+}
+
+func algumNaN(valores []float64) bool {
+	for _, v := range valores {
+		if math.IsNaN(v) {
+			return true
+		}
+	}
+	return false
+}
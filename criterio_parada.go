@@ -0,0 +1,189 @@
+package metodos
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/pkg/errors"
+)
+
+// Combinacao define como as tolerâncias ativas de um CriterioParada se combinam.
+type Combinacao string
+
+const (
+	// Ou para assim que qualquer tolerância ativa for satisfeita.
+	Ou Combinacao = "ou"
+	// E só para quando todas as tolerâncias ativas forem satisfeitas.
+	E Combinacao = "e"
+)
+
+// CriterioParada decide quando um método iterativo termina. Tolerâncias iguais
+// a zero ficam desativadas. Os limites de iterações e de avaliações, quando
+// positivos, sempre interrompem o método, que devolve a última aproximação.
+//
+// Nos integradores o passo é a diferença entre dois refinamentos sucessivos e
+// o resíduo não se aplica.
+type CriterioParada struct {
+	// PassoAbsoluto para quando |x_k - x_{k-1}| < PassoAbsoluto.
+	PassoAbsoluto float64 `json:"passoAbsoluto"`
+	// PassoRelativo para quando |x_k - x_{k-1}| < PassoRelativo·|x_k|.
+	PassoRelativo float64 `json:"passoRelativo"`
+	// Residuo para quando |f(x_k)| < Residuo.
+	Residuo       float64    `json:"residuo"`
+	MaxIteracoes  int        `json:"maxIteracoes"`
+	MaxAvaliacoes int        `json:"maxAvaliacoes"`
+	Combinacao    Combinacao `json:"combinacao"`
+}
+
+// NewCriterioParada devolve o critério padrão para uma precisão de 10^-k: para
+// quando o passo absoluto, o passo relativo ou o resíduo ficam abaixo de
+// 10^-k. O teste relativo é o que encerra as integrais de valor grande, cujo
+// erro absoluto nunca chega a 10^-k.
+func NewCriterioParada(k int) CriterioParada {
+	precisaoEsperada := math.Pow10(-k)
+	return CriterioParada{
+		PassoAbsoluto: precisaoEsperada,
+		PassoRelativo: precisaoEsperada,
+		Residuo:       precisaoEsperada,
+		Combinacao:    Ou,
+	}
+}
+
+func (c CriterioParada) validar() error {
+	if c.PassoAbsoluto < 0 || c.PassoRelativo < 0 || c.Residuo < 0 || c.MaxIteracoes < 0 || c.MaxAvaliacoes < 0 {
+		return errors.New("o critério de parada não aceita valores negativos")
+	}
+	switch c.Combinacao {
+	case "", Ou, E:
+	default:
+		return errors.Errorf("combinação de critérios desconhecida: %s", c.Combinacao)
+	}
+	if c.PassoAbsoluto == 0 && c.PassoRelativo == 0 && c.Residuo == 0 && c.MaxIteracoes == 0 && c.MaxAvaliacoes == 0 {
+		return errors.New("o critério de parada não tem tolerância nem limite")
+	}
+	return nil
+}
+
+// validarIntegracao valida o critério de um integrador, que não calcula
+// resíduo: só com Residuo o critério nunca seria satisfeito.
+func (c CriterioParada) validarIntegracao() error {
+	if err := c.validar(); err != nil {
+		return err
+	}
+	if c.PassoAbsoluto == 0 && c.PassoRelativo == 0 && c.MaxIteracoes == 0 && c.MaxAvaliacoes == 0 {
+		return errors.New("o resíduo não se aplica aos integradores; informe uma tolerância de passo ou um limite")
+	}
+	return nil
+}
+
+// estadoIteracao descreve o fim de uma iteração para o CriterioParada.
+type estadoIteracao struct {
+	iteracoes  int
+	avaliacoes int
+	x          float64
+	xAnterior  float64
+	// fx é NaN quando o método não calcula f na nova aproximação
+	fx float64
+}
+
+func (c CriterioParada) convergiu(e estadoIteracao) bool {
+	return c.convergiuPasso(math.Abs(e.x-e.xAnterior), math.Abs(e.x), e.fx)
+}
+
+// convergiuPasso aplica as tolerâncias a um passo já medido; modulo é o da
+// nova aproximação, a escala do teste relativo.
+func (c CriterioParada) convergiuPasso(passo, modulo, fx float64) bool {
+	if fx == 0 {
+		return true
+	}
+	ativos, satisfeitos := 0, 0
+	if c.PassoAbsoluto > 0 {
+		ativos++
+		if passo < c.PassoAbsoluto {
+			satisfeitos++
+		}
+	}
+	if c.PassoRelativo > 0 {
+		ativos++
+		if passo < c.PassoRelativo*modulo {
+			satisfeitos++
+		}
+	}
+	if c.Residuo > 0 && !math.IsNaN(fx) {
+		ativos++
+		if math.Abs(fx) < c.Residuo {
+			satisfeitos++
+		}
+	}
+	if c.Combinacao == E {
+		return ativos > 0 && satisfeitos == ativos
+	}
+	return satisfeitos > 0
+}
+
+// esgotado devolve o motivo da parada quando algum limite foi atingido.
+func (c CriterioParada) esgotado(e estadoIteracao) string {
+	if c.MaxIteracoes > 0 && e.iteracoes >= c.MaxIteracoes {
+		return fmt.Sprintf("limite de %d iterações atingido sem convergência", c.MaxIteracoes)
+	}
+	if c.MaxAvaliacoes > 0 && e.avaliacoes >= c.MaxAvaliacoes {
+		return fmt.Sprintf("limite de %d avaliações atingido sem convergência", c.MaxAvaliacoes)
+	}
+	return ""
+}
+
+// parar registra o estado no resultado e informa se o método deve terminar.
+// Ao terminar, estima a ordem de convergência pelos passos registrados.
+func (c CriterioParada) parar(resultado *Resultado, e estadoIteracao) bool {
+	resultado.Valor = e.x
+	resultado.Historico = append(resultado.Historico, e.x)
+	return c.encerrar(resultado, e.iteracoes, e.avaliacoes, math.Abs(e.x-e.xAnterior), math.Abs(e.x), e.fx)
+}
+
+// pararComplexo é o parar das iterações no plano complexo, como a de Muller:
+// o passo e a escala do teste relativo são módulos de números complexos, e o
+// histórico guarda as partes real e imaginária de cada aproximação.
+func (c CriterioParada) pararComplexo(resultado *Resultado, iteracoes, avaliacoes int, z, anterior complex128, residuo float64) bool {
+	resultado.Valor, resultado.Imaginario = real(z), imag(z)
+	resultado.Historico = append(resultado.Historico, real(z))
+	resultado.HistoricoImaginario = append(resultado.HistoricoImaginario, imag(z))
+	return c.encerrar(resultado, iteracoes, avaliacoes, cmplx.Abs(z-anterior), cmplx.Abs(z), residuo)
+}
+
+func (c CriterioParada) encerrar(resultado *Resultado, iteracoes, avaliacoes int, passo, modulo, fx float64) bool {
+	resultado.Iteracoes = iteracoes
+	resultado.Avaliacoes = avaliacoes
+	resultado.passos = append(resultado.passos, passo)
+	if c.convergiuPasso(passo, modulo, fx) {
+		resultado.Convergiu = true
+	} else if motivo := c.esgotado(estadoIteracao{iteracoes: iteracoes, avaliacoes: avaliacoes}); motivo != "" {
+		resultado.Avisos = append(resultado.Avisos, motivo)
+	} else {
+		return false
+	}
+	if ordem, err := ordemPelosPassos(resultado.passos, modulo); err == nil {
+		resultado.Ordem = &ordem
+	}
+	return true
+}
+
+// toleranciaPasso é a tolerância em x usada por rotinas auxiliares, como o
+// isolamento de raízes, que não iteram sob o critério.
+func (c CriterioParada) toleranciaPasso() float64 {
+	if c.PassoAbsoluto > 0 {
+		return c.PassoAbsoluto
+	}
+	if c.Residuo > 0 {
+		return c.Residuo
+	}
+	return 1e-8
+}
+
+// toleranciaResiduo é a tolerância em f usada por rotinas auxiliares.
+func (c CriterioParada) toleranciaResiduo() float64 {
+	if c.Residuo > 0 {
+		return c.Residuo
+	}
+	return c.toleranciaPasso()
+}
@@ -0,0 +1,337 @@
+package metodos
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// As definições do usuário são funções auxiliares escritas como
+// "g(t) = t**2 + 1", que o corpo e as outras definições chamam pelo nome.
+// Cada chamada é expandida no lugar, com os argumentos substituindo os
+// parâmetros, antes da compilação, então elas não custam nada na avaliação.
+// Uma definição pode chamar as seguintes, mas não a si mesma, nem direta nem
+// indiretamente. Os parâmetros são locais: em "g(t) = t + x", x é a variável
+// da expressão mesmo quando g é chamada de dentro de uma definição que tem
+// um parâmetro x.
+
+// maxNosExpansao limita o tamanho da árvore depois da expansão, que cresce
+// exponencialmente com definições que chamam a anterior mais de uma vez.
+const maxNosExpansao = 100000
+
+type definicao struct {
+	// indice é a posição da definição, a partir de 1
+	indice     int
+	nome       string
+	coluna     int
+	parametros []string
+	corpo      no
+	// expandida é o corpo sem chamadas a outras definições
+	expandida no
+}
+
+// analisarDefinicoes analisa as definições, que não podem usar os nomes
+// das variáveis nem das constantes. Com variaveis nil, os corpos aceitam
+// qualquer nome.
+func analisarDefinicoes(textos []string, variaveis []string, valores map[string]float64) (map[string]*definicao, error) {
+	if len(textos) == 0 {
+		return nil, nil
+	}
+	definicoes := make(map[string]*definicao, len(textos))
+	ordem := make([]*definicao, len(textos))
+	// os cabeçalhos vêm primeiro, para que uma definição chame as seguintes
+	for i, texto := range textos {
+		p := analisador{texto: []rune(texto)}
+		p.avancar()
+		d, err := p.cabecalho(valores)
+		if err != nil {
+			return nil, naDefinicao(err, i+1)
+		}
+		d.indice = i + 1
+		if motivo := conflitoDefinicao(d.nome, definicoes, variaveis, valores); motivo != "" {
+			return nil, &ErroExpressao{Mensagem: motivo, Coluna: d.coluna, Comprimento: len([]rune(d.nome)), Definicao: d.indice}
+		}
+		definicoes[d.nome] = d
+		ordem[i] = d
+	}
+
+	for i, texto := range textos {
+		d := ordem[i]
+		p := analisador{texto: []rune(texto), definicoes: definicoes}
+		p.avancar()
+		if _, err := p.cabecalho(valores); err != nil {
+			return nil, naDefinicao(err, d.indice)
+		}
+		corpo, err := p.comparacao()
+		if err == nil && p.token != "" {
+			err = p.erro(fmt.Sprintf("símbolo inesperado %q", p.token))
+		}
+		if err == nil && variaveis != nil {
+			conhecidas := append(append(append([]string{}, d.parametros...), variaveis...), nomesOrdenados(valores)...)
+			err = validarVariaveis(corpo, conhecidas)
+		}
+		if err != nil {
+			return nil, naDefinicao(err, d.indice)
+		}
+		d.corpo = substituirVariaveis(corpo, d.locais())
+	}
+
+	e := expansor{definicoes: definicoes}
+	for _, d := range ordem {
+		if err := e.definicao(d); err != nil {
+			return nil, err
+		}
+	}
+	return definicoes, nil
+}
+
+// cabecalho lê "nome(p1, p2, ...) =" e deixa o analisador no início do corpo.
+func (p *analisador) cabecalho(valores map[string]float64) (*definicao, error) {
+	if p.numero || !identificador(p.token) {
+		return nil, p.erro("era esperado o nome da função, como em g(t) = t**2 + 1")
+	}
+	d := &definicao{nome: p.token, coluna: p.inicio + 1}
+	p.avancar()
+	if p.token != "(" {
+		return nil, p.erro(fmt.Sprintf("era esperado \"(\" com os parâmetros de %s", d.nome))
+	}
+	p.avancar()
+	for p.token != ")" {
+		if p.numero || !identificador(p.token) {
+			return nil, p.erro("era esperado o nome de um parâmetro")
+		}
+		_, predefinida := constantes[p.token]
+		_, doUsuario := valores[p.token]
+		switch {
+		case predefinida || doUsuario:
+			return nil, p.erro(fmt.Sprintf("o parâmetro %s tem o nome de uma constante", p.token))
+		case contemNome(d.parametros, p.token):
+			return nil, p.erro(fmt.Sprintf("parâmetro %s repetido", p.token))
+		}
+		d.parametros = append(d.parametros, p.token)
+		p.avancar()
+		if p.token != "," {
+			break
+		}
+		p.avancar()
+	}
+	if p.token != ")" {
+		if p.token == "" {
+			return nil, p.erro("fim inesperado da definição")
+		}
+		return nil, p.erro(fmt.Sprintf("símbolo inesperado %q, era esperado \",\" ou \")\"", p.token))
+	}
+	p.avancar()
+	if p.token != "=" {
+		return nil, p.erro(fmt.Sprintf("era esperado \"=\" antes do corpo de %s", d.nome))
+	}
+	p.avancar()
+	return d, nil
+}
+
+// locais dá aos parâmetros nomes que não podem ser escritos numa expressão,
+// para que, ao substituí-los pelos argumentos, as variáveis de mesmo nome
+// que vêm dos argumentos ou do resto do corpo não sejam capturadas.
+func (d *definicao) locais() map[string]no {
+	locais := make(map[string]no, len(d.parametros))
+	for i, parametro := range d.parametros {
+		locais[parametro] = variavel{nome: d.local(i)}
+	}
+	return locais
+}
+
+func (d *definicao) local(i int) string {
+	return d.nome + "·" + d.parametros[i]
+}
+
+// conflitoDefinicao explica por que nome não pode ser dado a uma definição,
+// ou devolve "".
+func conflitoDefinicao(nome string, definicoes map[string]*definicao, variaveis []string, valores map[string]float64) string {
+	_, predefinida := constantes[nome]
+	_, doUsuario := valores[nome]
+	switch {
+	case nomeDeFuncao(nome):
+		return fmt.Sprintf("a função %s já existe", nome)
+	case definicoes[nome] != nil:
+		return fmt.Sprintf("a função %s já foi definida", nome)
+	case predefinida || doUsuario:
+		return fmt.Sprintf("%s já é o nome de uma constante", nome)
+	case contemNome(variaveis, nome):
+		return fmt.Sprintf("%s já é o nome de uma variável", nome)
+	}
+	return ""
+}
+
+func contemNome(nomes []string, nome string) bool {
+	for _, n := range nomes {
+		if n == nome {
+			return true
+		}
+	}
+	return false
+}
+
+// naDefinicao marca o erro de expressão como ocorrido na definição indice.
+func naDefinicao(err error, indice int) error {
+	if erro, ok := err.(*ErroExpressao); ok {
+		erro.Definicao = indice
+	}
+	return err
+}
+
+func nomesDefinicoes(definicoes map[string]*definicao) []string {
+	nomes := make([]string, 0, len(definicoes))
+	for nome := range definicoes {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	return nomes
+}
+
+// expansor troca as chamadas às definições pelos seus corpos.
+type expansor struct {
+	definicoes map[string]*definicao
+	// pilha guarda as definições em expansão, para detectar a recursão
+	pilha []string
+}
+
+// expandirDefinicoes devolve raiz sem chamadas às definições.
+func expandirDefinicoes(raiz no, definicoes map[string]*definicao) (no, error) {
+	if len(definicoes) == 0 {
+		return raiz, nil
+	}
+	e := expansor{definicoes: definicoes}
+	raiz, err := e.expandir(raiz, 0)
+	if err != nil {
+		return nil, err
+	}
+	if tamanho(raiz, maxNosExpansao) > maxNosExpansao {
+		return nil, errors.Errorf("a expressão passa de %d nós ao expandir as definições", maxNosExpansao)
+	}
+	return raiz, nil
+}
+
+// definicao expande o corpo de d, se ainda não foi expandido.
+func (e *expansor) definicao(d *definicao) error {
+	if d.expandida != nil {
+		return nil
+	}
+	e.pilha = append(e.pilha, d.nome)
+	corpo, err := e.expandir(d.corpo, d.indice)
+	e.pilha = e.pilha[:len(e.pilha)-1]
+	if err != nil {
+		return err
+	}
+	if tamanho(corpo, maxNosExpansao) > maxNosExpansao {
+		return &ErroExpressao{
+			Mensagem:    fmt.Sprintf("a expansão de %s passa de %d nós", d.nome, maxNosExpansao),
+			Coluna:      d.coluna,
+			Comprimento: len([]rune(d.nome)),
+			Definicao:   d.indice,
+		}
+	}
+	d.expandida = corpo
+	return nil
+}
+
+func (e *expansor) expandir(n no, onde int) (no, error) {
+	switch n := n.(type) {
+	case negacao:
+		operando, err := e.expandir(n.operando, onde)
+		if err != nil {
+			return nil, err
+		}
+		return negacao{operando}, nil
+	case binario:
+		esquerda, err := e.expandir(n.esquerda, onde)
+		if err != nil {
+			return nil, err
+		}
+		direita, err := e.expandir(n.direita, onde)
+		if err != nil {
+			return nil, err
+		}
+		return binario{n.op, esquerda, direita}, nil
+	case chamada:
+		argumentos, err := e.argumentos(n.argumentos, onde)
+		if err != nil {
+			return nil, err
+		}
+		return chamada{n.funcao, argumentos}, nil
+	case condicional:
+		ramos, err := e.argumentos([]no{n.condicao, n.entao, n.senao}, onde)
+		if err != nil {
+			return nil, err
+		}
+		return condicional{ramos[0], ramos[1], ramos[2]}, nil
+	case chamadaDefinida:
+		for i, nome := range e.pilha {
+			if nome == n.nome {
+				ciclo := append(append([]string{}, e.pilha[i:]...), n.nome)
+				return nil, &ErroExpressao{
+					Mensagem:    "definição recursiva: " + strings.Join(ciclo, " → "),
+					Coluna:      n.coluna,
+					Comprimento: len([]rune(n.nome)),
+					Definicao:   onde,
+				}
+			}
+		}
+		d := e.definicoes[n.nome]
+		if err := e.definicao(d); err != nil {
+			return nil, err
+		}
+		argumentos, err := e.argumentos(n.argumentos, onde)
+		if err != nil {
+			return nil, err
+		}
+		valores := make(map[string]no, len(argumentos))
+		for i := range d.parametros {
+			valores[d.local(i)] = argumentos[i]
+		}
+		return substituirVariaveis(d.expandida, valores), nil
+	}
+	return n, nil
+}
+
+func (e *expansor) argumentos(argumentos []no, onde int) ([]no, error) {
+	expandidos := make([]no, len(argumentos))
+	for i, a := range argumentos {
+		var err error
+		if expandidos[i], err = e.expandir(a, onde); err != nil {
+			return nil, err
+		}
+	}
+	return expandidos, nil
+}
+
+// tamanho conta os nós da árvore, parando ao passar de limite. As subárvores
+// compartilhadas pela expansão contam uma vez por ocorrência.
+func tamanho(n no, limite int) int {
+	total := 0
+	var contar func(n no)
+	contar = func(n no) {
+		if total > limite || n == nil {
+			return
+		}
+		total++
+		switch n := n.(type) {
+		case negacao:
+			contar(n.operando)
+		case binario:
+			contar(n.esquerda)
+			contar(n.direita)
+		case chamada:
+			for _, a := range n.argumentos {
+				contar(a)
+			}
+		case condicional:
+			contar(n.condicao)
+			contar(n.entao)
+			contar(n.senao)
+		}
+	}
+	contar(n)
+	return total
+}
@@ -0,0 +1,56 @@
+package metodos
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ErroDominio indica que a expressão não está definida no ponto avaliado:
+// uma função ou operador recebeu argumentos fora do seu domínio, como em
+// logn(-1), sqrt(-2), (-8)**(1/3) ou 0/0. Um NaN que vem de um estouro, como
+// Inf - Inf, não é erro de domínio. X é o valor do parâmetro, ou da primeira
+// variável da expressão quando ela não declara parâmetro.
+//
+// Cada método trata o erro conforme o que ele permite:
+//   - os métodos de intervalo, o ponto fixo, a multiplicidade e as regras de
+//     integração com nós fixos param e devolvem o erro, pois não há valor
+//     razoável para o ponto;
+//   - Newton-Raphson, secante e Halley reduzem o passo à metade até voltar ao
+//     domínio, registrando a salvaguarda SalvaguardaDominio;
+//   - IsolarRaizes e BuscarRaizes ignoram, com um aviso, as amostras e os
+//     intervalos em que f não está definida;
+//   - a quadratura tanh-sinh ignora, com um aviso, os nós colados a um
+//     extremo em que f deixa de estar definida, e para nos demais.
+type ErroDominio struct {
+	Funcao string  `json:"funcao"`
+	X      float64 `json:"x"`
+}
+
+func (e *ErroDominio) Error() string {
+	return fmt.Sprintf("a expressão não está definida em x = %g: argumento fora do domínio de %s", e.X, e.Funcao)
+}
+
+// foraDoDominio diz se err é, ou envolve, um *ErroDominio.
+func foraDoDominio(err error) bool {
+	_, ok := errors.Cause(err).(*ErroDominio)
+	return ok
+}
+
+// maxReducoesDominio limita quantas vezes um passo é reduzido à metade à
+// procura de um ponto em que f esteja definida.
+const maxReducoesDominio = 30
+
+// passoNoDominio avalia f em p, o ponto proposto a partir de x, e, enquanto
+// ele cair fora do domínio de f, o aproxima de x pela metade, registrando a
+// salvaguarda.
+func passoNoDominio(funcao ExpressaoAvaliavel, x, p float64, resultado *Resultado) (float64, float64, error) {
+	for k := 0; ; k++ {
+		fp, err := funcao.avaliarEm(p)
+		if !foraDoDominio(err) || k == maxReducoesDominio {
+			return p, fp, err
+		}
+		resultado.registrarSalvaguarda(SalvaguardaDominio)
+		p = x + (p-x)/2
+	}
+}
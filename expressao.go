@@ -4,95 +4,217 @@ import (
 	"github.com/pkg/errors"
 
 	"math"
-
-	"github.com/Knetic/govaluate"
 )
 
 // Expressao ...
+//
+// No JSON, A e B são strings e aceitam "inf" e "-inf" para integrais impróprias.
+//
+// Além do parâmetro, o corpo pode usar as variáveis de Variaveis, que só
+// recebem valor em Avaliar, e os nomes de Constantes, fixados na compilação:
+// com Corpo "k*exp(-a*t)", Parametro "t" e Constantes {"k": 2, "a": 0.5}, basta
+// trocar Constantes para variar k e a sem editar o corpo.
+//
+// Definicoes são funções auxiliares, como "g(t) = t**2 + 1", que o corpo e as
+// outras definições podem chamar: com elas, Corpo pode ser só "sin(g(x))/g(x)".
 type Expressao struct {
-	Corpo     string  `json:"corpo"`
-	Parametro string  `json:"parametro"`
-	A         float64 `json:"a,string"`
-	B         float64 `json:"b,string"`
+	Corpo      string             `json:"corpo"`
+	Parametro  string             `json:"parametro"`
+	Variaveis  []string           `json:"variaveis,omitempty"`
+	Constantes map[string]float64 `json:"constantes,omitempty"`
+	Definicoes []string           `json:"definicoes,omitempty"`
+	A          float64            `json:"a,string"`
+	B          float64            `json:"b,string"`
 }
 
+// ExpressaoAvaliavel é uma Expressao já compilada. Ela não pode ser avaliada
+// por várias goroutines ao mesmo tempo.
 type ExpressaoAvaliavel struct {
-	eval *govaluate.EvaluableExpression
-	expr Expressao
+	programa *programa
+	expr     Expressao
+	// avaliacoes é compartilhado entre as cópias da expressão
+	avaliacoes *int
+	// variaveis e pilha são reaproveitadas a cada avaliação
+	variaveis []float64
+	pilha     []float64
+	// parametro é a posição de expr.Parametro em variaveis, ou -1
+	parametro int
+	// desconhecida é a primeira variável diferente do parâmetro, se houver
+	desconhecida string
+	// condicoes são as funções cujas trocas de sinal mudam o valor de alguma
+	// comparação ou condição, usadas para achar os pontos de quebra
+	condicoes []ExpressaoAvaliavel
 }
 
 func (e *ExpressaoAvaliavel) Avaliar(params map[string]interface{}) (float64, error) {
-	if e == nil {
+	if e == nil || e.programa == nil {
 		return 0.0, errors.New("tentativa de avaliar uma expressão nula")
 	}
-	return evaluateEvaluableExpression(e.eval, params)
+	for i, nome := range e.programa.variaveis {
+		valor, ok := params[nome]
+		if !ok {
+			return 0.0, errors.Errorf("variável %q sem valor", nome)
+		}
+		v, ok := valor.(float64)
+		if !ok {
+			return 0.0, errors.Errorf("o valor da variável %q não é um float64", nome)
+		}
+		e.variaveis[i] = v
+	}
+	if e.avaliacoes != nil {
+		*e.avaliacoes++
+	}
+	v, falha := e.programa.executar(e.variaveis, e.pilha)
+	if falha >= 0 {
+		x := 0.0
+		if e.parametro >= 0 {
+			x = e.variaveis[e.parametro]
+		} else if len(e.variaveis) > 0 {
+			x = e.variaveis[0]
+		}
+		return v, &ErroDominio{Funcao: e.programa.instrucoes[falha].nome(), X: x}
+	}
+	return v, nil
+}
+
+// Avaliacoes devolve quantas vezes a expressão já foi avaliada.
+func (e *ExpressaoAvaliavel) Avaliacoes() int {
+	if e == nil || e.avaliacoes == nil {
+		return 0
+	}
+	return *e.avaliacoes
 }
 
+// avaliarEm avalia a expressão com o parâmetro valendo x, sem alocar memória.
+func (e *ExpressaoAvaliavel) avaliarEm(x float64) (float64, error) {
+	if e == nil || e.programa == nil {
+		return 0.0, errors.New("tentativa de avaliar uma expressão nula")
+	}
+	if e.desconhecida != "" {
+		return 0.0, errors.Errorf("variável %q sem valor", e.desconhecida)
+	}
+	if e.parametro >= 0 {
+		e.variaveis[e.parametro] = x
+	}
+	if e.avaliacoes != nil {
+		*e.avaliacoes++
+	}
+	v, falha := e.programa.executar(e.variaveis, e.pilha)
+	if falha >= 0 {
+		return v, &ErroDominio{Funcao: e.programa.instrucoes[falha].nome(), X: x}
+	}
+	return v, nil
+}
+
+// novaContagem devolve uma cópia da expressão que conta as avaliações a
+// partir de zero, para que cada execução de um método, em BuscarRaizes e nos
+// múltiplos chutes, tenha o seu próprio limite de avaliações.
+func (e ExpressaoAvaliavel) novaContagem() ExpressaoAvaliavel {
+	e.avaliacoes = new(int)
+	return e
+}
+
+// limitesFinitos recusa intervalos infinitos nas regras que avaliam f em
+// pontos igualmente espaçados.
+func (e Expressao) limitesFinitos() error {
+	if math.IsInf(e.A, 0) || math.IsInf(e.B, 0) {
+		return errors.New("as regras de Newton-Cotes não aceitam limites infinitos; use a quadratura de Gauss-Kronrod")
+	}
+	return nil
+}
+
+// comIntervalo devolve uma cópia da expressão restrita ao intervalo [a, b].
+func (e ExpressaoAvaliavel) comIntervalo(a, b float64) ExpressaoAvaliavel {
+	e.expr.A = a
+	e.expr.B = b
+	return e
+}
+
+// NewExpressaoAvaliavel compila a expressão. Com Parametro ou Variaveis
+// definidos, só eles são aceitos como variáveis; sem nenhum dos dois, qualquer
+// nome é aceito e recebe seu valor em Avaliar. Os erros de compilação do
+// corpo são do tipo *ErroExpressao.
 func NewExpressaoAvaliavel(expr Expressao) (ExpressaoAvaliavel, error) {
-	e, err := newEvaluableExpression(expr.Corpo)
-	if err != nil {
-		return ExpressaoAvaliavel{}, errors.Wrap(err, "expressão inválida")
+	return compilarExpressao(expr, expr.declaradas())
+}
+
+// declaradas devolve o parâmetro e as variáveis da expressão, ou nil se ela
+// não declarar nenhum.
+func (e Expressao) declaradas() []string {
+	if e.Parametro == "" && len(e.Variaveis) == 0 {
+		return nil
+	}
+	variaveis := make([]string, 0, len(e.Variaveis)+1)
+	if e.Parametro != "" {
+		variaveis = append(variaveis, e.Parametro)
 	}
-	return ExpressaoAvaliavel{e, expr}, nil
+	return append(variaveis, e.Variaveis...)
 }
 
-func evaluateEvaluableExpression(expr *govaluate.EvaluableExpression, params map[string]interface{}) (float64, error) {
-	params["e"] = math.E
-	params["pi"] = math.Pi
-	t, err := expr.Evaluate(params)
+// compilarExpressao compila a expressão aceitando só os nomes de variaveis,
+// ou qualquer nome se variaveis for nil.
+func compilarExpressao(expr Expressao, variaveis []string) (ExpressaoAvaliavel, error) {
+	if math.IsNaN(expr.A) || math.IsNaN(expr.B) {
+		return ExpressaoAvaliavel{}, errors.New("os limites A e B não podem ser NaN")
+	}
+	raiz, err := prepararExpressao(expr, variaveis)
 	if err != nil {
-		return 0.0, err
+		return ExpressaoAvaliavel{}, err
+	}
+	e := novaExpressaoAvaliavel(raiz, expr)
+	for _, g := range condicoesDe(simplificar(raiz)) {
+		e.condicoes = append(e.condicoes, novaExpressaoAvaliavel(g, expr))
 	}
-	result, ok := t.(float64)
-	if !ok {
-		return 0.0, errors.New("impossível converter o resultado para float64")
+	return e, nil
+}
+
+func novaExpressaoAvaliavel(raiz no, expr Expressao) ExpressaoAvaliavel {
+	p := compilar(raiz)
+	e := ExpressaoAvaliavel{
+		programa:   &p,
+		expr:       expr,
+		avaliacoes: new(int),
+		variaveis:  make([]float64, len(p.variaveis)),
+		pilha:      make([]float64, p.profundidade),
+		parametro:  -1,
+	}
+	for i, nome := range p.variaveis {
+		if nome == expr.Parametro {
+			e.parametro = i
+		} else if e.desconhecida == "" {
+			e.desconhecida = nome
+		}
 	}
-	return result, nil
+	return e
 }
 
-func newEvaluableExpression(expr string) (*govaluate.EvaluableExpression, error) {
-	functions := map[string]govaluate.ExpressionFunction{
-		"cos": func(args ...interface{}) (interface{}, error) {
-			c := math.Cos(args[0].(float64))
-			return (float64)(c), nil
-		},
-		"sin": func(args ...interface{}) (interface{}, error) {
-			c := math.Sin(args[0].(float64))
-			return (float64)(c), nil
-		},
-		"abs": func(args ...interface{}) (interface{}, error) {
-			c := math.Abs(args[0].(float64))
-			return (float64)(c), nil
-		},
-		"log2": func(args ...interface{}) (interface{}, error) {
-			c := math.Log2(args[0].(float64))
-			if c == math.NaN() {
-				return 0.0, nil
-			}
-			return (float64)(c), nil
-		},
-		"log": func(args ...interface{}) (interface{}, error) {
-			c := math.Log10(args[0].(float64))
-			if c == math.NaN() {
-				return 0.0, nil
-			}
-			return (float64)(c), nil
-		},
-		"logn": func(args ...interface{}) (interface{}, error) {
-			c := math.Log(args[0].(float64))
-			if c == math.NaN() {
-				return 0.0, nil
-			}
-			return (float64)(c), nil
-		},
-		"tan": func(args ...interface{}) (interface{}, error) {
-			c := math.Tan(args[0].(float64))
-			return (float64)(c), nil
-		},
-	}
-	evaluable, err := govaluate.NewEvaluableExpressionWithFunctions(expr, functions)
+// prepararExpressao analisa o corpo e as definições, verifica seus nomes e
+// devolve a árvore com as definições expandidas e as constantes do usuário
+// trocadas pelos seus valores.
+func prepararExpressao(expr Expressao, variaveis []string) (no, error) {
+	if err := validarConstantes(expr.Constantes, variaveis); err != nil {
+		return nil, err
+	}
+	definicoes, err := analisarDefinicoes(expr.Definicoes, variaveis, expr.Constantes)
 	if err != nil {
-		return &govaluate.EvaluableExpression{}, err
+		return nil, err
+	}
+	raiz, err := analisar(expr.Corpo, definicoes)
+	if err != nil {
+		return nil, err
+	}
+	if variaveis != nil {
+		conhecidas := append(append([]string{}, variaveis...), nomesOrdenados(expr.Constantes)...)
+		if err := validarVariaveis(raiz, conhecidas); err != nil {
+			return nil, err
+		}
+	}
+	if raiz, err = expandirDefinicoes(raiz, definicoes); err != nil {
+		return nil, err
+	}
+	valores := make(map[string]no, len(expr.Constantes))
+	for nome, v := range expr.Constantes {
+		valores[nome] = numero{v}
 	}
-	return evaluable, nil
+	return substituirVariaveis(raiz, valores), nil
 }
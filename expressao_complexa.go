@@ -0,0 +1,184 @@
+package metodos
+
+import (
+	"math"
+	"math/cmplx"
+
+	"github.com/pkg/errors"
+)
+
+// expressaoComplexa avalia o corpo de uma Expressao em pontos complexos,
+// com a mesma sintaxe e as mesmas funções das avaliações reais.
+type expressaoComplexa struct {
+	raiz       noComplexo
+	avaliacoes int
+}
+
+type noComplexo func(z complex128) (complex128, error)
+
+func newExpressaoComplexa(expr Expressao) (expressaoComplexa, error) {
+	raiz, err := prepararExpressao(expr, append([]string{expr.Parametro}, expr.Variaveis...))
+	if err != nil {
+		return expressaoComplexa{}, err
+	}
+	f, err := compilarComplexo(raiz, expr.Parametro)
+	if err != nil {
+		return expressaoComplexa{}, errors.Wrap(err, "expressão inválida")
+	}
+	return expressaoComplexa{raiz: f}, nil
+}
+
+func (e *expressaoComplexa) avaliar(z complex128) (complex128, error) {
+	e.avaliacoes++
+	return e.raiz(z)
+}
+
+func compilarComplexo(n no, parametro string) (noComplexo, error) {
+	switch n := n.(type) {
+	case numero:
+		return func(complex128) (complex128, error) { return complex(n.valor, 0), nil }, nil
+	case variavel:
+		if v, ok := constantes[n.nome]; ok {
+			return func(complex128) (complex128, error) { return complex(v, 0), nil }, nil
+		}
+		if n.nome != parametro {
+			return nil, errors.Errorf("variável %q sem valor", n.nome)
+		}
+		return func(z complex128) (complex128, error) { return z, nil }, nil
+	case negacao:
+		operando, err := compilarComplexo(n.operando, parametro)
+		if err != nil {
+			return nil, err
+		}
+		return func(z complex128) (complex128, error) {
+			v, err := operando(z)
+			return -v, err
+		}, nil
+	case binario:
+		esquerda, err := compilarComplexo(n.esquerda, parametro)
+		if err != nil {
+			return nil, err
+		}
+		direita, err := compilarComplexo(n.direita, parametro)
+		if err != nil {
+			return nil, err
+		}
+		return binarioComplexo(esquerda, direita, n.op), nil
+	case chamada:
+		argumentos := make([]noComplexo, len(n.argumentos))
+		for i, a := range n.argumentos {
+			var err error
+			if argumentos[i], err = compilarComplexo(a, parametro); err != nil {
+				return nil, err
+			}
+		}
+		f := funcoesMatematicas[n.funcao]
+		return func(z complex128) (complex128, error) {
+			valores := make([]complex128, len(argumentos))
+			reais := make([]float64, len(argumentos))
+			complexos := false
+			for i, a := range argumentos {
+				v, err := a(z)
+				if err != nil {
+					return 0, err
+				}
+				valores[i], reais[i] = v, real(v)
+				complexos = complexos || imag(v) != 0
+			}
+			if f.complexa != nil {
+				return f.complexa(valores), nil
+			}
+			// as demais funções só são definidas na reta real
+			if complexos {
+				return 0, errors.Errorf("a função %s não é definida para números complexos", f.nome)
+			}
+			v := f.real(reais)
+			if math.IsNaN(v) && !algumNaN(reais) {
+				return 0, &ErroDominio{Funcao: f.nome, X: real(z)}
+			}
+			return complex(v, 0), nil
+		}, nil
+	case condicional:
+		condicao, err := compilarComplexo(n.condicao, parametro)
+		if err != nil {
+			return nil, err
+		}
+		entao, err := compilarComplexo(n.entao, parametro)
+		if err != nil {
+			return nil, err
+		}
+		var senao noComplexo
+		if n.senao != nil {
+			if senao, err = compilarComplexo(n.senao, parametro); err != nil {
+				return nil, err
+			}
+		}
+		return func(z complex128) (complex128, error) {
+			c, err := condicao(z)
+			switch {
+			case err != nil:
+				return 0, err
+			case imag(c) != 0:
+				return 0, errors.New("as condições de if e piecewise não podem ser complexas")
+			case real(c) != 0:
+				return entao(z)
+			case senao == nil:
+				return 0, &ErroDominio{Funcao: "piecewise", X: real(z)}
+			}
+			return senao(z)
+		}, nil
+	}
+	return nil, errors.Errorf("nó desconhecido %T", n)
+}
+
+func binarioComplexo(esquerda, direita noComplexo, op byte) noComplexo {
+	return func(z complex128) (complex128, error) {
+		a, err := esquerda(z)
+		if err != nil {
+			return 0, err
+		}
+		b, err := direita(z)
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '+':
+			return a + b, nil
+		case '-':
+			return a - b, nil
+		case '*':
+			return a * b, nil
+		case '/':
+			return a / b, nil
+		case '%':
+			if imag(a) != 0 || imag(b) != 0 {
+				return 0, errors.New("o operador % não é definido para números complexos")
+			}
+			return complex(math.Mod(real(a), real(b)), 0), nil
+		}
+		if operadorComparacao(op) {
+			if imag(a) != 0 || imag(b) != 0 {
+				return 0, errors.Errorf("o operador %s não é definido para números complexos", simboloOperador(op))
+			}
+			return complex(operacao(op, real(a), real(b)), 0), nil
+		}
+		return potenciaComplexa(a, b), nil
+	}
+}
+
+// potenciaComplexa usa multiplicações sucessivas para expoentes inteiros,
+// evitando a parte imaginária espúria de cmplx.Pow com bases reais negativas.
+func potenciaComplexa(base, expoente complex128) complex128 {
+	n := real(expoente)
+	if imag(expoente) != 0 || n != math.Trunc(n) || math.Abs(n) > 64 {
+		return cmplx.Pow(base, expoente)
+	}
+	resultado := complex(1, 0)
+	for i := 0; i < int(math.Abs(n)); i++ {
+		resultado *= base
+	}
+	if n < 0 {
+		return 1 / resultado
+	}
+	return resultado
+}
@@ -0,0 +1,457 @@
+package metodos
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/Knetic/govaluate"
+	"github.com/pkg/errors"
+)
+
+// govaluateReferencia avalia corpo como a versão anterior da biblioteca, que
+// interpretava a expressão com o govaluate a cada chamada.
+func govaluateReferencia(t testing.TB, corpo string) func(x float64) float64 {
+	funcao := func(f func(float64) float64) govaluate.ExpressionFunction {
+		return func(args ...interface{}) (interface{}, error) {
+			return f(args[0].(float64)), nil
+		}
+	}
+	funcoes := map[string]govaluate.ExpressionFunction{
+		"cos":  funcao(math.Cos),
+		"sin":  funcao(math.Sin),
+		"abs":  funcao(math.Abs),
+		"log2": funcao(math.Log2),
+		"log":  funcao(math.Log10),
+		"logn": funcao(math.Log),
+		"tan":  funcao(math.Tan),
+	}
+	expr, err := govaluate.NewEvaluableExpressionWithFunctions(corpo, funcoes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := make(map[string]interface{}, 3)
+	return func(x float64) float64 {
+		params["x"] = x
+		params["e"] = math.E
+		params["pi"] = math.Pi
+		v, err := expr.Evaluate(params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v.(float64)
+	}
+}
+
+var expressoesReferencia = []string{
+	"x**2",
+	"-x**2",
+	"2**3**2",
+	"2 - 3 - x",
+	"x / 2 / 4",
+	"x % 3 * 2",
+	"-(x**2) + 3*x - 1",
+	"e**(-(x**2))",
+	"sin(x)**2 + cos(x)**2",
+	"pi * abs(x - 1) / tan(x + 0.5)",
+	"log2(x + 4) + log(x + 4) - logn(x + 4)",
+	"(x - 1) * (x - 2.05)**2",
+	"1 / (x - 0.25)",
+	"x**0.5",
+}
+
+func TestCompiladorEquivalenteAoGovaluate(t *testing.T) {
+	for _, corpo := range expressoesReferencia {
+		referencia := govaluateReferencia(t, corpo)
+		expr, err := NewExpressaoAvaliavel(Expressao{Corpo: corpo, Parametro: "x"})
+		if err != nil {
+			t.Fatalf("%s: %v", corpo, err)
+		}
+		for _, x := range []float64{-2.5, -1, -0.3, 0, 0.25, 0.7, 1, 3.2, 10} {
+			obtido, err := expr.avaliarEm(x)
+			esperado := referencia(x)
+			// onde o govaluate dava NaN, a avaliação acusa o erro de domínio
+			if math.IsNaN(esperado) {
+				if _, ok := err.(*ErroDominio); !ok {
+					t.Errorf("%s em %v: esperado *ErroDominio, obtido %v (%v)", corpo, x, obtido, err)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%s em %v: %v", corpo, x, err)
+			}
+			if obtido != esperado && math.Abs(obtido-esperado) > 1e-15*math.Abs(esperado) {
+				t.Errorf("%s em %v: esperado %v, obtido %v", corpo, x, esperado, obtido)
+			}
+		}
+	}
+}
+
+func TestCompiladorErros(t *testing.T) {
+	for _, corpo := range []string{"", "x +", "(x", "x)", "foo(x)", "sin(x, 2)", "1..2", "x $ 2",
+		"sin(x,)", "max(x,)", "piecewise(x < 0, 1,)", "if(x, 1, 2,)"} {
+		if _, err := NewExpressaoAvaliavel(Expressao{Corpo: corpo, Parametro: "x"}); err == nil {
+			t.Errorf("%q deveria ser recusada", corpo)
+		}
+	}
+
+	// sem parâmetro declarado, as variáveis recebem valor em Avaliar
+	expr, err := NewExpressaoAvaliavel(Expressao{Corpo: "x + y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expr.Avaliar(map[string]interface{}{"x": 1.0}); err == nil {
+		t.Error("a variável y sem valor deveria ser acusada")
+	}
+	v, err := expr.Avaliar(map[string]interface{}{"x": 1.0, "y": 2.0})
+	if err != nil || v != 3 {
+		t.Errorf("esperado 3, obtido %v (%v)", v, err)
+	}
+}
+
+func TestCompiladorSemAlocacoes(t *testing.T) {
+	expr, err := NewExpressaoAvaliavel(Expressao{Corpo: "sin(x)**2*cos(3*x) + e**(-(x**2))", Parametro: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x := 0.0
+	alocacoes := testing.AllocsPerRun(100, func() {
+		x += 0.01
+		expr.avaliarEm(x)
+	})
+	if alocacoes != 0 {
+		t.Errorf("esperado nenhuma alocação por avaliação, obtidas %v", alocacoes)
+	}
+}
+
+func BenchmarkAvaliacaoGovaluate(b *testing.B) {
+	f := govaluateReferencia(b, integrandoCaro.Corpo)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f(float64(i%300) / 100)
+	}
+}
+
+func BenchmarkAvaliacaoCompilada(b *testing.B) {
+	expr, err := NewExpressaoAvaliavel(integrandoCaro)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		expr.avaliarEm(float64(i%300) / 100)
+	}
+}
+
+func TestDiagnosticoExpressao(t *testing.T) {
+	casos := []struct {
+		corpo       string
+		coluna      int
+		comprimento int
+		sugestao    string
+	}{
+		{"x + ", 5, 0, ""},
+		{"(x + 1", 1, 1, ""},
+		{"2 * (x + 1))", 12, 1, ""},
+		{"x $ 2", 3, 1, ""},
+		{"sen(x) + 1", 1, 3, "sin"},
+		{"2 * cso(x)", 5, 3, "cos"},
+		{"x**2 + y", 8, 1, "x"},
+		{"2*pii", 3, 3, "pi"},
+		{"sin + 1", 1, 3, ""},
+		{"sin(x, 2)", 1, 3, ""},
+		{"1..2 + x", 1, 4, ""},
+	}
+	for _, c := range casos {
+		_, err := NewExpressaoAvaliavel(Expressao{Corpo: c.corpo, Parametro: "x"})
+		erro, ok := errors.Cause(err).(*ErroExpressao)
+		if !ok {
+			t.Errorf("%q: esperado *ErroExpressao, obtido %v", c.corpo, err)
+			continue
+		}
+		if erro.Coluna != c.coluna || erro.Comprimento != c.comprimento || erro.Sugestao != c.sugestao {
+			t.Errorf("%q: esperado coluna %d, comprimento %d e sugestão %q, obtido %+v",
+				c.corpo, c.coluna, c.comprimento, c.sugestao, erro)
+		}
+	}
+
+	avisos, err := ValidarExpressao(Expressao{Corpo: "2*pi", Parametro: "x"})
+	if err != nil || len(avisos) != 1 {
+		t.Errorf("esperado um aviso de parâmetro sem uso, obtido %v (%v)", avisos, err)
+	}
+	if avisos, err := ValidarExpressao(Expressao{Corpo: "x*pi", Parametro: "x"}); err != nil || len(avisos) != 0 {
+		t.Errorf("nenhum aviso esperado, obtido %v (%v)", avisos, err)
+	}
+
+	// nas integrais múltiplas, o limite de uma variável só vê as externas
+	integral := IntegralMultipla{Corpo: "x*y", Limites: []Limite{{"x", "0", "y"}, {"y", "0", "1"}}}
+	_, err = IntegrarMultipla(integral, RegraGaussKronrod, NewCriterioParada(5))
+	if erro, ok := errors.Cause(err).(*ErroExpressao); !ok || erro.Coluna != 1 {
+		t.Errorf("esperado erro na variável y do limite de x, obtido %v", err)
+	}
+}
+
+func TestFuncoesMatematicas(t *testing.T) {
+	casos := map[string]float64{
+		"sqrt(2)**2":                2,
+		"exp(logn(3))":              3,
+		"asin(1) + acos(1)":         math.Pi / 2,
+		"atan(1)*4":                 math.Pi,
+		"atan2(-1, -1)":             -3 * math.Pi / 4,
+		"cosh(x)**2 - sinh(x)**2":   1,
+		"tanh(atanh(0.5))":          0.5,
+		"asinh(sinh(2))":            2,
+		"acosh(cosh(2))":            2,
+		"floor(-2.5) + ceil(2.1)":   0,
+		"round(2.5) + round(-2.5)":  0,
+		"min(3, x, -4) + max(x, 7)": 3,
+		"sign(-3) + sign(0)":        -1,
+		"gamma(5)":                  24,
+		"factorial(5)":              120,
+		"erf(x) + erfc(x)":          1,
+		"besselj(0, 0)":             1,
+		"besselj(1, 0)":             0,
+		"hypot(3, 4)":               5,
+	}
+	for corpo, esperado := range casos {
+		expr, err := NewExpressaoAvaliavel(Expressao{Corpo: corpo, Parametro: "x"})
+		if err != nil {
+			t.Errorf("%s: %v", corpo, err)
+			continue
+		}
+		obtido, err := expr.avaliarEm(0.7)
+		if err != nil || math.Abs(obtido-esperado) > 1e-12 {
+			t.Errorf("%s: esperado %v, obtido %v (%v)", corpo, esperado, obtido, err)
+		}
+	}
+
+	// o número e a natureza dos argumentos são verificados na compilação
+	for _, corpo := range []string{"sqrt()", "atan2(x)", "hypot(1, 2, 3)", "min()", "factorial(-1)", "factorial(2.5)", "besselj(0.5, x)"} {
+		if _, err := NewExpressaoAvaliavel(Expressao{Corpo: corpo, Parametro: "x"}); err == nil {
+			t.Errorf("%q deveria ser recusada", corpo)
+		}
+	}
+	expr, err := NewExpressaoAvaliavel(Expressao{Corpo: "factorial(x)", Parametro: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expr.avaliarEm(2.5); !reflect.DeepEqual(err, &ErroDominio{Funcao: "factorial", X: 2.5}) {
+		t.Errorf("factorial(2.5) deveria estar fora do domínio, obtido %v", err)
+	}
+
+	// subexpressões constantes fora do domínio não viram NaN na compilação
+	for corpo, funcao := range map[string]string{"sqrt(-1)*x": "sqrt", "x + 0/0": "/", "(-8)**(1/3) - x": "**"} {
+		expr, err := NewExpressaoAvaliavel(Expressao{Corpo: corpo, Parametro: "x"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := expr.avaliarEm(1); !reflect.DeepEqual(err, &ErroDominio{Funcao: funcao, X: 1}) {
+			t.Errorf("%s: esperado erro de domínio em %s, obtido %v", corpo, funcao, err)
+		}
+	}
+
+	raiz, err := newExpressaoComplexa(Expressao{Corpo: "sqrt(x)", Parametro: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := raiz.avaliar(-4); err != nil || v != 2i {
+		t.Errorf("sqrt(-4) deveria ser 2i, obtido %v (%v)", v, err)
+	}
+	piso, err := newExpressaoComplexa(Expressao{Corpo: "floor(x)", Parametro: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := piso.avaliar(2.5); err != nil || v != 2 {
+		t.Errorf("floor(2.5) deveria ser 2, obtido %v (%v)", v, err)
+	}
+	if _, err := piso.avaliar(2.5 + 1i); err == nil {
+		t.Error("floor não deveria aceitar argumentos complexos")
+	}
+}
+
+func TestConstantesEVariaveis(t *testing.T) {
+	// varredura de parâmetros sem editar o corpo
+	for _, a := range []float64{0.5, 1, 2} {
+		decaimento := Expressao{Corpo: "k*exp(-a*t)", Parametro: "t", Constantes: map[string]float64{"k": 2, "a": a}, A: 0, B: 1}
+		r, err := GaussKronrod(decaimento, NewCriterioParada(10))
+		if esperado := 2 * (1 - math.Exp(-a)) / a; err != nil || math.Abs(r.Valor-esperado) > 1e-10 {
+			t.Errorf("a = %v: esperado %v, obtido %v (%v)", a, esperado, r.Valor, err)
+		}
+	}
+
+	// a derivada herda as constantes de f
+	r, err := NewtonRalphson(Expressao{Corpo: "x**2 - c", Parametro: "x", Constantes: map[string]float64{"c": 2}},
+		Expressao{Corpo: "2*x"}, 1, NewCriterioParada(10))
+	if err != nil || math.Abs(r.Valor-math.Sqrt2) > 1e-9 {
+		t.Errorf("raiz esperada √2, obtida %v (%v)", r.Valor, err)
+	}
+
+	expr, err := NewExpressaoAvaliavel(Expressao{Corpo: "x*y + k", Parametro: "x", Variaveis: []string{"y"}, Constantes: map[string]float64{"k": 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := expr.Avaliar(map[string]interface{}{"x": 2.0, "y": 3.0}); err != nil || v != 7 {
+		t.Errorf("esperado 7, obtido %v (%v)", v, err)
+	}
+	if _, err := expr.avaliarEm(2); err == nil {
+		t.Error("a variável y sem valor deveria ser acusada")
+	}
+
+	recusadas := []Expressao{
+		{Corpo: "x*z", Parametro: "x", Variaveis: []string{"y"}},
+		{Corpo: "x*k", Parametro: "x", Constantes: map[string]float64{"pi": 3}},
+		{Corpo: "x*k", Parametro: "x", Constantes: map[string]float64{"sin": 1}},
+		{Corpo: "x*k", Parametro: "x", Constantes: map[string]float64{"x": 1}},
+		{Corpo: "x*k", Parametro: "x", Constantes: map[string]float64{"1k": 1}},
+		{Corpo: "x*k", Parametro: "x", Constantes: map[string]float64{"k": math.NaN()}},
+	}
+	for _, e := range recusadas {
+		if _, err := NewExpressaoAvaliavel(e); err == nil {
+			t.Errorf("%+v deveria ser recusada", e)
+		}
+	}
+	_, err = NewExpressaoAvaliavel(Expressao{Corpo: "x*kk", Parametro: "x", Constantes: map[string]float64{"k": 1}})
+	if erro, ok := errors.Cause(err).(*ErroExpressao); !ok || erro.Sugestao != "k" {
+		t.Errorf("esperada a sugestão da constante k, obtido %v", err)
+	}
+
+	integral := IntegralMultipla{Corpo: "k*x*y", Limites: []Limite{{"x", "0", "L"}, {"y", "0", "L"}}, Constantes: map[string]float64{"k": 4, "L": 1}}
+	if r, err := IntegrarMultipla(integral, RegraGaussKronrod, NewCriterioParada(8)); err != nil || math.Abs(r.Valor-1) > 1e-8 {
+		t.Errorf("integral múltipla: esperado 1, obtido %v (%v)", r.Valor, err)
+	}
+}
+
+func TestDefinicoes(t *testing.T) {
+	// uma definição pode chamar as seguintes
+	definicoes := []string{"f(x) = sin(g(x))/g(x)", "g(t) = t**2 + 1", "d(x, y) = x - y"}
+	casos := map[string]float64{
+		"f(x)":          math.Sin(1.49) / 1.49,
+		"d(1, x)":       0.3,
+		"d(d(x, 1), x)": -1,
+		"g(g(x)) - 1":   1.49 * 1.49,
+	}
+	for corpo, esperado := range casos {
+		expr, err := NewExpressaoAvaliavel(Expressao{Corpo: corpo, Parametro: "x", Definicoes: definicoes})
+		if err != nil {
+			t.Errorf("%s: %v", corpo, err)
+			continue
+		}
+		if v, err := expr.avaliarEm(0.7); err != nil || math.Abs(v-esperado) > 1e-12 {
+			t.Errorf("%s: esperado %v, obtido %v (%v)", corpo, esperado, v, err)
+		}
+	}
+
+	// os parâmetros não capturam as variáveis de mesmo nome
+	captura := []struct {
+		expressao Expressao
+		valores   map[string]interface{}
+		esperado  float64
+	}{
+		{Expressao{Corpo: "f(1)", Parametro: "x", Definicoes: []string{"g(t) = t + x", "f(x) = g(x**2)"}}, map[string]interface{}{"x": 5.0}, 6},
+		{Expressao{Corpo: "g(2)", Parametro: "x", Variaveis: []string{"y"}, Definicoes: []string{"f(x) = x + y", "g(y) = f(y)"}}, map[string]interface{}{"x": 0.0, "y": 10.0}, 12},
+	}
+	for _, c := range captura {
+		expr, err := NewExpressaoAvaliavel(c.expressao)
+		if err != nil {
+			t.Errorf("%v: %v", c.expressao.Definicoes, err)
+			continue
+		}
+		if v, err := expr.Avaliar(c.valores); err != nil || v != c.esperado {
+			t.Errorf("%v: esperado %v, obtido %v (%v)", c.expressao.Definicoes, c.esperado, v, err)
+		}
+	}
+
+	raiz, err := newExpressaoComplexa(Expressao{Corpo: "g(x)", Parametro: "x", Definicoes: definicoes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := raiz.avaliar(1i); err != nil || v != 0 {
+		t.Errorf("g(i) deveria ser 0, obtido %v (%v)", v, err)
+	}
+
+	erros := []struct {
+		definicoes []string
+		corpo      string
+		definicao  int
+		coluna     int
+		mensagem   string
+	}{
+		{[]string{"g(t) = f(t) + 1", "f(t) = 2*g(t)"}, "g(x)", 2, 10, "definição recursiva: g → f → g"},
+		{[]string{"fat(n) = n*fat(n - 1)"}, "fat(x)", 1, 12, "definição recursiva: fat → fat"},
+		{[]string{"g(t) = t + z"}, "g(x)", 1, 12, ""},
+		{[]string{"sin(t) = t"}, "x", 1, 1, ""},
+		{[]string{"g t = t"}, "x", 1, 3, ""},
+		{[]string{"g(t, t) = t"}, "x", 1, 6, ""},
+		{[]string{"g(t) = t"}, "g(x, 1)", 0, 1, ""},
+	}
+	for _, c := range erros {
+		_, err := NewExpressaoAvaliavel(Expressao{Corpo: c.corpo, Parametro: "x", Definicoes: c.definicoes})
+		erro, ok := errors.Cause(err).(*ErroExpressao)
+		if !ok || erro.Definicao != c.definicao || erro.Coluna != c.coluna || (c.mensagem != "" && erro.Mensagem != c.mensagem) {
+			t.Errorf("%v: esperado erro na coluna %d da definição %d, obtido %v", c.definicoes, c.coluna, c.definicao, err)
+		}
+	}
+
+	// a expansão de definições encadeadas cresce exponencialmente
+	encadeadas := []string{"f0(x) = x"}
+	for k := 1; k <= 20; k++ {
+		encadeadas = append(encadeadas, fmt.Sprintf("f%d(x) = f%d(x) + f%d(x)", k, k-1, k-1))
+	}
+	if _, err := NewExpressaoAvaliavel(Expressao{Corpo: "f20(x)", Parametro: "x", Definicoes: encadeadas}); err == nil {
+		t.Error("a expansão grande demais deveria ser recusada")
+	}
+}
+
+func TestCondicionais(t *testing.T) {
+	casos := map[string]float64{
+		"if(x > 1, 2*x, -x)":                     -0.7,
+		"piecewise(x < 0, -1, x < 1, x, 1)":      0.7,
+		"piecewise(x < 0.5, 1, x < 0.6, 2, 3)":   3,
+		"(x >= 0.7) + (x == 0.7) + (x != 1)":     3,
+		"(x <= 0.5) + (x < 0.7) + 2*x > 1":       1,
+		"if(x > 0, x, sqrt(-1))":                 0.7,
+		"if(1 < 2, x, 0)":                        0.7,
+		"piecewise(x > 2, sqrt(x - 2), 0.5)":     0.5,
+		"if(x, 1, 2) + if(x - 0.7, 1, 2)":        3,
+		"piecewise(x < 1, logn(x), logn(1 - x))": math.Log(0.7),
+	}
+	for corpo, esperado := range casos {
+		expr, err := NewExpressaoAvaliavel(Expressao{Corpo: corpo, Parametro: "x"})
+		if err != nil {
+			t.Errorf("%s: %v", corpo, err)
+			continue
+		}
+		if v, err := expr.avaliarEm(0.7); err != nil || math.Abs(v-esperado) > 1e-15 {
+			t.Errorf("%s: esperado %v, obtido %v (%v)", corpo, esperado, v, err)
+		}
+	}
+
+	// sem valor padrão, piecewise não está definida fora das condições
+	expr, err := NewExpressaoAvaliavel(Expressao{Corpo: "piecewise(x < 0, 1)", Parametro: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expr.avaliarEm(0.7); !reflect.DeepEqual(err, &ErroDominio{Funcao: "piecewise", X: 0.7}) {
+		t.Errorf("esperado erro de domínio em piecewise, obtido %v", err)
+	}
+
+	for _, corpo := range []string{"if(x, 1)", "piecewise(x)", "x = 1", "if(x > 0, 1, 2", "x < "} {
+		if _, err := NewExpressaoAvaliavel(Expressao{Corpo: corpo, Parametro: "x"}); err == nil {
+			t.Errorf("%q deveria ser recusada", corpo)
+		}
+	}
+
+	complexa, err := newExpressaoComplexa(Expressao{Corpo: "if(x > 0, 1, 2)", Parametro: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := complexa.avaliar(-1); err != nil || v != 2 {
+		t.Errorf("esperado 2, obtido %v (%v)", v, err)
+	}
+	if _, err := complexa.avaliar(1i); err == nil {
+		t.Error("comparações complexas deveriam ser recusadas")
+	}
+}
@@ -0,0 +1,162 @@
+package metodos
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+)
+
+// funcaoMatematica é uma função que pode ser chamada nas expressões.
+type funcaoMatematica struct {
+	nome string
+	// minimo e maximo limitam o número de argumentos; maximo < 0 não limita
+	minimo, maximo int
+	real           func(args []float64) float64
+	// complexa é nil nas funções sem extensão aos complexos, que só aceitam
+	// argumentos reais nas avaliações complexas
+	complexa func(args []complex128) complex128
+	// inteiro é a posição, a partir de 1, do argumento que precisa ser um
+	// inteiro, ou 0; naoNegativo exige ainda que ele não seja negativo
+	inteiro     int
+	naoNegativo bool
+}
+
+func umReal(f func(float64) float64) func([]float64) float64 {
+	return func(args []float64) float64 { return f(args[0]) }
+}
+
+func doisReais(f func(float64, float64) float64) func([]float64) float64 {
+	return func(args []float64) float64 { return f(args[0], args[1]) }
+}
+
+func umComplexo(f func(complex128) complex128) func([]complex128) complex128 {
+	return func(args []complex128) complex128 { return f(args[0]) }
+}
+
+// funcoesMatematicas são as funções aceitas nas expressões. Argumentos fora
+// do domínio, como sqrt(-1) nos reais ou factorial(2.5), dão NaN, que a
+// avaliação transforma num *ErroDominio.
+var funcoesMatematicas = []funcaoMatematica{
+	{nome: "cos", minimo: 1, maximo: 1, real: umReal(math.Cos), complexa: umComplexo(cmplx.Cos)},
+	{nome: "sin", minimo: 1, maximo: 1, real: umReal(math.Sin), complexa: umComplexo(cmplx.Sin)},
+	{nome: "tan", minimo: 1, maximo: 1, real: umReal(math.Tan), complexa: umComplexo(cmplx.Tan)},
+	{nome: "abs", minimo: 1, maximo: 1, real: umReal(math.Abs), complexa: umComplexo(func(z complex128) complex128 {
+		return complex(cmplx.Abs(z), 0)
+	})},
+	{nome: "log2", minimo: 1, maximo: 1, real: umReal(math.Log2), complexa: umComplexo(func(z complex128) complex128 {
+		return cmplx.Log(z) / math.Ln2
+	})},
+	{nome: "log", minimo: 1, maximo: 1, real: umReal(math.Log10), complexa: umComplexo(cmplx.Log10)},
+	{nome: "logn", minimo: 1, maximo: 1, real: umReal(math.Log), complexa: umComplexo(cmplx.Log)},
+	{nome: "sqrt", minimo: 1, maximo: 1, real: umReal(math.Sqrt), complexa: umComplexo(cmplx.Sqrt)},
+	{nome: "exp", minimo: 1, maximo: 1, real: umReal(math.Exp), complexa: umComplexo(cmplx.Exp)},
+	{nome: "asin", minimo: 1, maximo: 1, real: umReal(math.Asin), complexa: umComplexo(cmplx.Asin)},
+	{nome: "acos", minimo: 1, maximo: 1, real: umReal(math.Acos), complexa: umComplexo(cmplx.Acos)},
+	{nome: "atan", minimo: 1, maximo: 1, real: umReal(math.Atan), complexa: umComplexo(cmplx.Atan)},
+	{nome: "atan2", minimo: 2, maximo: 2, real: doisReais(math.Atan2)},
+	{nome: "sinh", minimo: 1, maximo: 1, real: umReal(math.Sinh), complexa: umComplexo(cmplx.Sinh)},
+	{nome: "cosh", minimo: 1, maximo: 1, real: umReal(math.Cosh), complexa: umComplexo(cmplx.Cosh)},
+	{nome: "tanh", minimo: 1, maximo: 1, real: umReal(math.Tanh), complexa: umComplexo(cmplx.Tanh)},
+	{nome: "asinh", minimo: 1, maximo: 1, real: umReal(math.Asinh), complexa: umComplexo(cmplx.Asinh)},
+	{nome: "acosh", minimo: 1, maximo: 1, real: umReal(math.Acosh), complexa: umComplexo(cmplx.Acosh)},
+	{nome: "atanh", minimo: 1, maximo: 1, real: umReal(math.Atanh), complexa: umComplexo(cmplx.Atanh)},
+	{nome: "floor", minimo: 1, maximo: 1, real: umReal(math.Floor)},
+	{nome: "ceil", minimo: 1, maximo: 1, real: umReal(math.Ceil)},
+	{nome: "round", minimo: 1, maximo: 1, real: umReal(math.Round)},
+	{nome: "min", minimo: 1, maximo: -1, real: menor},
+	{nome: "max", minimo: 1, maximo: -1, real: maior},
+	{nome: "sign", minimo: 1, maximo: 1, real: umReal(sinal)},
+	{nome: "gamma", minimo: 1, maximo: 1, real: umReal(math.Gamma)},
+	{nome: "erf", minimo: 1, maximo: 1, real: umReal(math.Erf)},
+	{nome: "erfc", minimo: 1, maximo: 1, real: umReal(math.Erfc)},
+	{nome: "besselj", minimo: 2, maximo: 2, real: doisReais(besselJ), inteiro: 1},
+	{nome: "factorial", minimo: 1, maximo: 1, real: umReal(fatorial), inteiro: 1, naoNegativo: true},
+	{nome: "hypot", minimo: 2, maximo: 2, real: doisReais(math.Hypot)},
+}
+
+func indiceFuncao(nome string) int {
+	for i, f := range funcoesMatematicas {
+		if f.nome == nome {
+			return i
+		}
+	}
+	return -1
+}
+
+// aceitaArgumentos diz se a função pode ser chamada com n argumentos.
+func (f funcaoMatematica) aceitaArgumentos(n int) bool {
+	return n >= f.minimo && (f.maximo < 0 || n <= f.maximo)
+}
+
+// aridade descreve o número de argumentos aceito.
+func (f funcaoMatematica) aridade() string {
+	switch {
+	case f.maximo < 0:
+		return "pelo menos " + quantosArgumentos(f.minimo)
+	case f.minimo == f.maximo:
+		return quantosArgumentos(f.minimo)
+	}
+	return fmt.Sprintf("de %d a %d argumentos", f.minimo, f.maximo)
+}
+
+func quantosArgumentos(n int) string {
+	switch n {
+	case 0:
+		return "nenhum argumento"
+	case 1:
+		return "um argumento"
+	}
+	return fmt.Sprintf("%d argumentos", n)
+}
+
+// argumentoValido verifica a exigência de inteiro sobre o valor v do
+// argumento na posição i, a partir de 1.
+func (f funcaoMatematica) argumentoValido(i int, v float64) bool {
+	if i != f.inteiro {
+		return true
+	}
+	return v == math.Trunc(v) && !(f.naoNegativo && v < 0)
+}
+
+func menor(args []float64) float64 {
+	r := args[0]
+	for _, v := range args[1:] {
+		r = math.Min(r, v)
+	}
+	return r
+}
+
+func maior(args []float64) float64 {
+	r := args[0]
+	for _, v := range args[1:] {
+		r = math.Max(r, v)
+	}
+	return r
+}
+
+func sinal(x float64) float64 {
+	switch {
+	case x > 0:
+		return 1
+	case x < 0:
+		return -1
+	}
+	// zero e NaN
+	return x
+}
+
+// besselJ é a função de Bessel de primeira espécie de ordem inteira n.
+func besselJ(n, x float64) float64 {
+	if n != math.Trunc(n) || math.Abs(n) > math.MaxInt32 {
+		return math.NaN()
+	}
+	return math.Jn(int(n), x)
+}
+
+// fatorial usa a função gama, que dá +Inf a partir de 171!.
+func fatorial(n float64) float64 {
+	if n != math.Trunc(n) || n < 0 {
+		return math.NaN()
+	}
+	return math.Gamma(n + 1)
+}
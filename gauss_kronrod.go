@@ -0,0 +1,248 @@
+package metodos
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ResultadoIntegral é o valor de uma integral junto com a estimativa do erro,
+// o número de avaliações do integrando e os avisos gerados durante o cálculo.
+type ResultadoIntegral struct {
+	Valor        float64 `json:"result"`
+	ErroEstimado float64 `json:"erroEstimado"`
+	// Convergiu é falso quando o erro estimado não atingiu a tolerância
+	Convergiu     bool     `json:"convergiu"`
+	Avaliacoes    int      `json:"avaliacoes"`
+	Subintervalos int      `json:"subintervalos,omitempty"`
+	Avisos        []string `json:"avisos,omitempty"`
+	// PontosDeQuebra são os pontos em que [A, B] foi dividido por causa das
+	// condições do integrando
+	PontosDeQuebra []float64 `json:"pontosDeQuebra,omitempty"`
+}
+
+const (
+	// maxSubintervalos limita a subdivisão mesmo sem limite no critério
+	maxSubintervalos = 2000
+	epsilonMaquina   = 2.220446049250313e-16
+)
+
+// GaussKronrod integra adaptativamente com a regra de Gauss-Kronrod G7-K15,
+// subdividindo sempre o subintervalo de maior erro estimado, como o QAG do
+// QUADPACK. É o integrador recomendado.
+//
+// PassoAbsoluto e PassoRelativo do critério são as tolerâncias absoluta e
+// relativa do erro estimado; MaxIteracoes limita o número de bissecções. Se
+// o erro estimado chega a 50·ε·∫|f|, abaixo do qual o arredondamento não
+// deixa ir, a subdivisão para com um aviso.
+// Limites infinitos são levados a (0, 1] por mudança de variável.
+func GaussKronrod(integral Expressao, criterio CriterioParada) (ResultadoIntegral, error) {
+	if err := criterio.validarIntegracao(); err != nil {
+		return ResultadoIntegral{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	expr, err := NewExpressaoAvaliavel(integral)
+	if err != nil {
+		return ResultadoIntegral{}, err
+	}
+
+	quebras := expr.pontosDeQuebra(integral.A, integral.B)
+	resultado, err := porPartes(quebras, integral.A, integral.B, criterio, func(t trecho, criterio CriterioParada) (ResultadoIntegral, error) {
+		f, a, b := intervaloFinito(t.interior(expr.avaliarEm), t.a, t.b)
+		return gaussKronrod(ctx, f, a, b, criterio)
+	})
+	// em (-∞, ∞) cada nó avalia f duas vezes
+	resultado.Avaliacoes = expr.Avaliacoes()
+	return resultado, err
+}
+
+// nós e pesos da regra de Kronrod de 15 pontos em [-1, 1]; os nós de índice
+// ímpar, junto com o central, formam a regra de Gauss de 7 pontos
+var (
+	nosKronrod15 = [8]float64{
+		0.991455371120812639206854697526329,
+		0.949107912342758524526189684047851,
+		0.864864423359769072789712788640926,
+		0.741531185599394439863864773280788,
+		0.586087235467691130294144845693013,
+		0.405845151377397166906606412076961,
+		0.207784955007898467600689403773245,
+		0,
+	}
+	pesosKronrod15 = [8]float64{
+		0.022935322010529224963732008058970,
+		0.063092092629978553290700663189204,
+		0.104790010322250183839876322541518,
+		0.140653259715525918745189590510238,
+		0.169004726639267902826583426598550,
+		0.190350578064785409913256402421014,
+		0.204432940075298892414161999234649,
+		0.209482141084727828012999174891714,
+	}
+	pesosGauss7 = [4]float64{
+		0.129484966168869693270611432679082,
+		0.279705391489276667901467771423780,
+		0.381830050505118944950369775488975,
+		0.417959183673469387755102040816327,
+	}
+)
+
+// segmento é um subintervalo com sua integral e seu erro estimados.
+type segmento struct {
+	a, b  float64
+	valor float64
+	erro  float64
+	// piso é o menor erro que a aritmética permite estimar no segmento
+	piso float64
+}
+
+// filaSegmentos é um heap de máximo pelo erro estimado.
+type filaSegmentos []segmento
+
+func (f filaSegmentos) Len() int            { return len(f) }
+func (f filaSegmentos) Less(i, j int) bool  { return f[i].erro > f[j].erro }
+func (f filaSegmentos) Swap(i, j int)       { f[i], f[j] = f[j], f[i] }
+func (f *filaSegmentos) Push(x interface{}) { *f = append(*f, x.(segmento)) }
+func (f *filaSegmentos) Pop() interface{} {
+	antiga := *f
+	n := len(antiga)
+	s := antiga[n-1]
+	*f = antiga[:n-1]
+	return s
+}
+
+// kronrod15 aplica a regra G7-K15 em [a, b] e estima o erro como o QK15 do
+// QUADPACK, a partir de |K15 - G7| escalado pela variação de f no intervalo.
+func kronrod15(f func(float64) (float64, error), a, b float64) (segmento, error) {
+	centro := (a + b) / 2
+	meio := (b - a) / 2
+
+	fc, err := f(centro)
+	if err != nil {
+		return segmento{}, err
+	}
+	var valores [15]float64
+	valores[7] = fc
+	kronrod := fc * pesosKronrod15[7]
+	gauss := fc * pesosGauss7[3]
+	for j := 0; j < 7; j++ {
+		dx := meio * nosKronrod15[j]
+		f1, err := f(centro - dx)
+		if err != nil {
+			return segmento{}, err
+		}
+		f2, err := f(centro + dx)
+		if err != nil {
+			return segmento{}, err
+		}
+		valores[j], valores[14-j] = f1, f2
+		kronrod += pesosKronrod15[j] * (f1 + f2)
+		if j%2 == 1 {
+			gauss += pesosGauss7[j/2] * (f1 + f2)
+		}
+	}
+
+	media := kronrod / 2
+	absoluta := pesosKronrod15[7] * math.Abs(fc)
+	variacao := pesosKronrod15[7] * math.Abs(fc-media)
+	for j := 0; j < 7; j++ {
+		absoluta += pesosKronrod15[j] * (math.Abs(valores[j]) + math.Abs(valores[14-j]))
+		variacao += pesosKronrod15[j] * (math.Abs(valores[j]-media) + math.Abs(valores[14-j]-media))
+	}
+	absoluta *= math.Abs(meio)
+	variacao *= math.Abs(meio)
+
+	erro := math.Abs((kronrod - gauss) * meio)
+	if variacao != 0 && erro != 0 {
+		erro = variacao * math.Min(1, math.Pow(200*erro/variacao, 1.5))
+	}
+	piso := 0.0
+	if absoluta > math.SmallestNonzeroFloat64/(50*epsilonMaquina) {
+		piso = 50 * epsilonMaquina * absoluta
+		erro = math.Max(piso, erro)
+	}
+	return segmento{a: a, b: b, valor: kronrod * meio, erro: erro, piso: piso}, nil
+}
+
+// gaussKronrod é o núcleo adaptativo, independente de Expressao para que
+// outros integradores possam reaproveitá-lo com integrandos transformados.
+func gaussKronrod(ctx context.Context, f func(float64) (float64, error), a, b float64, criterio CriterioParada) (ResultadoIntegral, error) {
+	var resultado ResultadoIntegral
+	if a == b {
+		resultado.Convergiu = true
+		return resultado, nil
+	}
+
+	inicial, err := kronrod15(f, a, b)
+	if err != nil {
+		return resultado, err
+	}
+	fila := filaSegmentos{inicial}
+	resultado.Avaliacoes = 15
+
+	// i conta as bissecções já feitas
+	valor, erro, piso := inicial.valor, inicial.erro, inicial.piso
+	for i := 0; ; i++ {
+		resultado.Valor, resultado.ErroEstimado, resultado.Subintervalos = valor, erro, len(fila)
+		estado := estadoIteracao{i, resultado.Avaliacoes, valor, valor - erro, math.NaN()}
+		if criterio.convergiu(estado) {
+			resultado.Convergiu = true
+			return resultado, nil
+		}
+		if motivo := criterio.esgotado(estado); motivo != "" {
+			resultado.Avisos = append(resultado.Avisos, motivo)
+			return resultado, nil
+		}
+		// como o ier = 2 do QUADPACK: subdividir não reduz mais o erro
+		if erro <= piso {
+			resultado.Avisos = append(resultado.Avisos, fmt.Sprintf("o erro estimado chegou ao limite do arredondamento, %g, sem atingir a tolerância", erro))
+			return resultado, nil
+		}
+		if len(fila) >= maxSubintervalos {
+			resultado.Avisos = append(resultado.Avisos, "limite de subintervalos atingido sem convergência")
+			return resultado, nil
+		}
+
+		pior := heap.Pop(&fila).(segmento)
+		meio := (pior.a + pior.b) / 2
+		if math.Abs(pior.b-pior.a) < 100*epsilonMaquina*math.Max(1, math.Abs(meio)) {
+			heap.Push(&fila, pior)
+			resultado.Avisos = append(resultado.Avisos, fmt.Sprintf("subintervalo pequeno demais perto de x = %g, possível singularidade", meio))
+			return resultado, nil
+		}
+
+		esquerda, err := kronrod15(f, pior.a, meio)
+		if err != nil {
+			return resultado, err
+		}
+		direita, err := kronrod15(f, meio, pior.b)
+		if err != nil {
+			return resultado, err
+		}
+		resultado.Avaliacoes += 30
+		heap.Push(&fila, esquerda)
+		heap.Push(&fila, direita)
+
+		// soma de novo para não acumular o erro de arredondamento das atualizações
+		valor, erro, piso = 0, 0, 0
+		for _, s := range fila {
+			valor += s.valor
+			erro += s.erro
+			piso += s.piso
+		}
+
+		select {
+		case <-ctx.Done():
+			resultado.Avisos = append(resultado.Avisos, "tempo esgotado antes da convergência")
+			return resultado, nil
+		default:
+			continue
+		}
+	}
+}
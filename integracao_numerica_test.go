@@ -1,11 +1,19 @@
 package metodos
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 var expr Expressao
-var k int
+var criterio CriterioParada
 
 func init() {
 	expr = Expressao{
@@ -14,45 +22,520 @@ func init() {
 		Corpo:     "x**2",
 		Parametro: "x",
 	}
-	k = 5
+	criterio = NewCriterioParada(5)
 }
 
 func BenchmarkRegraDeSimpson38Repetida(b *testing.B) {
-	var r float64
+	var r ResultadoIntegral
 	for i := 0; i < b.N; i++ {
-		v, _ := RegraDeSimpson38Repetida(expr, k)
+		v, _ := RegraDeSimpson38Repetida(expr, criterio)
 		r = v
 	}
-	_ = r
+	b.ReportMetric(float64(r.Avaliacoes), "avaliacoes/op")
 }
 
-
 func BenchmarkRegraDeSimpson13Repetida(b *testing.B) {
-	var r float64
+	var r ResultadoIntegral
 	for i := 0; i < b.N; i++ {
-		v, _ := RegraDeSimpson13Repetida(expr, k)
+		v, _ := RegraDeSimpson13Repetida(expr, criterio)
 		r = v
 	}
-	_ = r
+	b.ReportMetric(float64(r.Avaliacoes), "avaliacoes/op")
 }
 
-
 func BenchmarkRegraDosTrapeziosRepetida(b *testing.B) {
-	var r float64
+	var r ResultadoIntegral
 	for i := 0; i < b.N; i++ {
-		v, _ := RegraDosTrapeziosRepetida(expr, k)
+		v, _ := RegraDosTrapeziosRepetida(expr, criterio)
 		r = v
 	}
-	_ = r
+	b.ReportMetric(float64(r.Avaliacoes), "avaliacoes/op")
 }
 
 func BenchmarkRegraNewtonCotes4(b *testing.B) {
-	var r float64
+	var r ResultadoIntegral
+	for i := 0; i < b.N; i++ {
+		v, _ := RegraNewtonCotes4(expr, criterio)
+		r = v
+	}
+	b.ReportMetric(float64(r.Avaliacoes), "avaliacoes/op")
+}
+
+func BenchmarkGaussKronrod(b *testing.B) {
+	var r ResultadoIntegral
 	for i := 0; i < b.N; i++ {
-		v, _ := RegraNewtonCotes4(expr, k)
+		v, _ := GaussKronrod(expr, criterio)
 		r = v
 	}
 	_ = r
 }
 
+func TestGaussKronrod(t *testing.T) {
+	casos := []struct {
+		integral Expressao
+		esperado float64
+	}{
+		{Expressao{Corpo: "sin(x)", Parametro: "x", A: 0, B: math.Pi}, 2},
+		// resultado nulo, em que o critério relativo dos outros integradores falha
+		{Expressao{Corpo: "sin(x)", Parametro: "x", A: 0, B: 2 * math.Pi}, 0},
+		{Expressao{Corpo: "x**0.5", Parametro: "x", A: 0, B: 1}, 2.0 / 3},
+		{Expressao{Corpo: "logn(x)", Parametro: "x", A: 0, B: 1}, -1},
+		{Expressao{Corpo: "x**2", Parametro: "x", A: 4, B: 1}, -21},
+	}
+	for _, c := range casos {
+		r, err := GaussKronrod(c.integral, NewCriterioParada(10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Convergiu {
+			t.Errorf("%s em [%v, %v] não convergiu: %v", c.integral.Corpo, c.integral.A, c.integral.B, r.Avisos)
+		}
+		if erro := math.Abs(r.Valor - c.esperado); erro > 1e-9 || erro > r.ErroEstimado+1e-15 {
+			t.Errorf("%s em [%v, %v]: esperado %v, obtido %v com erro estimado %v", c.integral.Corpo, c.integral.A, c.integral.B, c.esperado, r.Valor, r.ErroEstimado)
+		}
+	}
+
+	// uma tolerância absoluta abaixo do arredondamento não é atingível
+	constante := Expressao{Corpo: "1", Parametro: "x", A: 0, B: 1e6}
+	r, err := GaussKronrod(constante, CriterioParada{PassoAbsoluto: 1e-12})
+	if err != nil || r.Valor != 1e6 || r.Convergiu || r.Avaliacoes != 15 || len(r.Avisos) != 1 {
+		t.Errorf("esperada a parada no limite do arredondamento, obtido %+v (%v)", r, err)
+	}
+
+	// MaxIteracoes é o número de bissecções
+	pico := Expressao{Corpo: "1/(0.0001 + x**2)", Parametro: "x", A: -1, B: 1}
+	r, err = GaussKronrod(pico, CriterioParada{PassoAbsoluto: 1e-12, MaxIteracoes: 3})
+	if err != nil || r.Convergiu || r.Subintervalos != 4 || r.Avaliacoes != 15+3*30 {
+		t.Errorf("esperadas 3 bissecções, obtido %+v (%v)", r, err)
+	}
+
+	// os integradores não calculam resíduo
+	if _, err := GaussKronrod(pico, CriterioParada{Residuo: 1e-8}); err == nil {
+		t.Error("um critério só de resíduo deveria ser recusado")
+	}
+}
+
+func TestIntegraisImproprias(t *testing.T) {
+	var gaussiana Expressao
+	if err := json.Unmarshal([]byte(`{"corpo": "e**(-(x**2))", "parametro": "x", "a": "0", "b": "inf"}`), &gaussiana); err != nil {
+		t.Fatal(err)
+	}
+	casos := []struct {
+		integral Expressao
+		esperado float64
+	}{
+		{gaussiana, math.Sqrt(math.Pi) / 2},
+		{Expressao{Corpo: "1/(1+x**2)", Parametro: "x", A: math.Inf(-1), B: math.Inf(1)}, math.Pi},
+		{Expressao{Corpo: "e**x", Parametro: "x", A: math.Inf(-1), B: 0}, 1},
+		{Expressao{Corpo: "1/x**2", Parametro: "x", A: math.Inf(1), B: 1}, -1},
+		{Expressao{Corpo: "e**x", Parametro: "x", A: math.Inf(1), B: math.Inf(1)}, 0},
+		{Expressao{Corpo: "e**x", Parametro: "x", A: math.Inf(-1), B: math.Inf(-1)}, 0},
+	}
+	for _, c := range casos {
+		r, err := GaussKronrod(c.integral, NewCriterioParada(10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Convergiu || math.Abs(r.Valor-c.esperado) > 1e-9 {
+			t.Errorf("%s em [%v, %v]: esperado %v, obtido %v (%v)", c.integral.Corpo, c.integral.A, c.integral.B, c.esperado, r.Valor, r.Avisos)
+		}
+	}
+
+	if _, err := RegraDosTrapeziosRepetida(gaussiana, NewCriterioParada(5)); err == nil {
+		t.Error("a regra dos trapézios deveria recusar limites infinitos")
+	}
+
+	// o intervalo vazio [∞, ∞] não avalia f
+	g, a, b := intervaloFinito(func(x float64) (float64, error) { return math.Exp(x), nil }, math.Inf(1), math.Inf(1))
+	if v, err := gaussKronrod(context.Background(), g, a, b, NewCriterioParada(10)); err != nil || v.Valor != 0 || v.Avaliacoes != 0 {
+		t.Errorf("[∞, ∞]: esperado 0 sem avaliações, obtido %+v (%v)", v, err)
+	}
+	vazia := IntegralMultipla{Corpo: "e**x", Limites: []Limite{{"x", "inf", "inf"}}}
+	if r, err := IntegrarMultipla(vazia, "", NewCriterioParada(10)); err != nil || r.Valor != 0 || r.ErroEstimado != 0 {
+		t.Errorf("[∞, ∞] na integral múltipla: esperado 0, obtido %+v (%v)", r, err)
+	}
+}
+
+func TestTanhSinh(t *testing.T) {
+	casos := []struct {
+		integral Expressao
+		esperado float64
+	}{
+		{Expressao{Corpo: "1/x**0.5", Parametro: "x", A: 0, B: 1}, 2},
+		{Expressao{Corpo: "logn(x)", Parametro: "x", A: 0, B: 1}, -1},
+		{Expressao{Corpo: "sin(x)", Parametro: "x", A: 0, B: math.Pi}, 2},
+		{Expressao{Corpo: "e**(-(x**2))", Parametro: "x", A: 0, B: math.Inf(1)}, math.Sqrt(math.Pi) / 2},
+	}
+	for _, c := range casos {
+		r, err := TanhSinh(c.integral, NewCriterioParada(12))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Convergiu || math.Abs(r.Valor-c.esperado) > 1e-10 {
+			t.Errorf("%s em [%v, %v]: esperado %v, obtido %v (%v)", c.integral.Corpo, c.integral.A, c.integral.B, c.esperado, r.Valor, r.Avisos)
+		}
+	}
+
+	// longe da origem, o espaçamento dos float64 limita a aproximação do extremo
+	r, err := TanhSinh(Expressao{Corpo: "1/(1-x)**0.5", Parametro: "x", A: 0, B: 1}, NewCriterioParada(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-2) > 1e-7 {
+		t.Errorf("1/sqrt(1-x) em [0, 1]: esperado 2, obtido %v", r.Valor)
+	}
+
+	// a integral diverge: cortar o lado em que f estoura não pode convergir
+	r, err = TanhSinh(Expressao{Corpo: "1/x", Parametro: "x", A: 0, B: 1}, NewCriterioParada(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Convergiu || len(r.Avisos) == 0 || !strings.Contains(r.Avisos[0], "diverge") {
+		t.Errorf("1/x em [0, 1] deveria avisar da divergência, obtido %v (%v)", r.Valor, r.Avisos)
+	}
+}
+
+func TestSingularidadeNoExtremo(t *testing.T) {
+	integral := Expressao{Corpo: "1/x**0.5", Parametro: "x", A: 0, B: 1}
+	regras := map[string]func(Expressao, CriterioParada) (ResultadoIntegral, error){
+		"trapézios":      RegraDosTrapeziosRepetida,
+		"Simpson 1/3":    RegraDeSimpson13Repetida,
+		"Simpson 3/8":    RegraDeSimpson38Repetida,
+		"Newton-Cotes 4": RegraNewtonCotes4,
+	}
+	for nome, regra := range regras {
+		if _, err := regra(integral, NewCriterioParada(5)); err == nil || !strings.Contains(err.Error(), "singular") {
+			t.Errorf("%s deveria acusar a singularidade em x = 0, devolveu %v", nome, err)
+		}
+	}
+
+	r, err := RegraDosTrapeziosRepetida(Expressao{Corpo: "x**2", Parametro: "x", A: 0, B: 1}, NewCriterioParada(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-1.0/3) > 1e-5 {
+		t.Errorf("regra dos trapézios: esperado 1/3, obtido %v", r.Valor)
+	}
+}
+
+func TestIntegrarMultipla(t *testing.T) {
+	casos := []struct {
+		nome     string
+		integral IntegralMultipla
+		regra    RegraMultipla
+		esperado float64
+	}{
+		{"triângulo", IntegralMultipla{Corpo: "x*y", Limites: []Limite{{"x", "0", "1"}, {"y", "0", "x"}}}, RegraGaussKronrod, 1.0 / 8},
+		{"triângulo por Simpson", IntegralMultipla{Corpo: "x*y", Limites: []Limite{{"x", "0", "1"}, {"y", "0", "x"}}}, RegraSimpson, 1.0 / 8},
+		{"tetraedro", IntegralMultipla{Corpo: "x + y + z", Limites: []Limite{{"x", "0", "1"}, {"y", "0", "1 - x"}, {"z", "0", "1 - x - y"}}}, RegraGaussKronrod, 1.0 / 8},
+		{"gaussiana no plano", IntegralMultipla{Corpo: "e**(-(x**2 + y**2))", Limites: []Limite{{"x", "-inf", "inf"}, {"y", "-inf", "inf"}}}, RegraGaussKronrod, math.Pi},
+	}
+	for _, c := range casos {
+		r, err := IntegrarMultipla(c.integral, c.regra, NewCriterioParada(8))
+		if err != nil {
+			t.Fatalf("%s: %v", c.nome, err)
+		}
+		if !r.Convergiu || math.Abs(r.Valor-c.esperado) > 1e-7 {
+			t.Errorf("%s: esperado %v, obtido %v (%v)", c.nome, c.esperado, r.Valor, r.Avisos)
+		}
+	}
+
+	// as tolerâncias internas não descem abaixo do arredondamento
+	tetraedro := casos[2].integral
+	r, err := IntegrarMultipla(tetraedro, RegraGaussKronrod, NewCriterioParada(13))
+	if err != nil || !r.Convergiu || len(r.Avisos) != 0 || math.Abs(r.Valor-1.0/8) > 1e-13 {
+		t.Errorf("tetraedro com 10^-13: esperado 1/8 sem avisos, obtido %v (%v, %v)", r.Valor, r.Avisos, err)
+	}
+
+	invalida := IntegralMultipla{Corpo: "x*y", Limites: []Limite{{"x", "0", "1"}, {"x", "0", "1"}}}
+	if _, err := IntegrarMultipla(invalida, RegraGaussKronrod, NewCriterioParada(8)); err == nil {
+		t.Error("variáveis repetidas deveriam ser recusadas")
+	}
+}
+
+func TestMonteCarlo(t *testing.T) {
+	limites := []Limite{{"a", "0", "1"}, {"b", "0", "1"}, {"c", "0", "1"}, {"d", "0", "1"}, {"e1", "0", "1"}}
+	integral := IntegralMultipla{Corpo: "a + b + c + d + e1", Limites: limites}
+	erros := make(map[AmostragemMonteCarlo]float64)
+	for _, amostragem := range []AmostragemMonteCarlo{AmostragemSimples, AmostragemEstratificada, AmostragemSobol, AmostragemHalton} {
+		r, err := MonteCarlo(integral, OpcoesMonteCarlo{Amostragem: amostragem, Amostras: 1 << 14, Semente: 42})
+		if err != nil {
+			t.Fatalf("%q: %v", amostragem, err)
+		}
+		if r.ErroEstimado <= 0 || math.Abs(r.Valor-2.5) > 5*r.ErroEstimado {
+			t.Errorf("%q: esperado 2.5, obtido %v ± %v", amostragem, r.Valor, r.ErroEstimado)
+		}
+		erros[amostragem] = r.ErroEstimado
 
+		// a semente, e não o número de goroutines, determina o resultado
+		outro, err := MonteCarlo(integral, OpcoesMonteCarlo{Amostragem: amostragem, Amostras: 1 << 14, Semente: 42, Trabalhadores: 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if outro.Valor != r.Valor {
+			t.Errorf("%q: resultados diferentes com a mesma semente: %v e %v", amostragem, r.Valor, outro.Valor)
+		}
+	}
+	if erros[AmostragemSobol] >= erros[AmostragemSimples] || erros[AmostragemEstratificada] >= erros[AmostragemSimples] {
+		t.Errorf("estratificação e quasi-Monte Carlo deveriam reduzir o erro: %v", erros)
+	}
+
+	triangulo := IntegralMultipla{Corpo: "x*y", Limites: []Limite{{"x", "0", "1"}, {"y", "0", "x"}}}
+	r, err := MonteCarlo(triangulo, OpcoesMonteCarlo{Amostragem: AmostragemSobol})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-1.0/8) > 1e-3 {
+		t.Errorf("triângulo: esperado 1/8, obtido %v ± %v", r.Valor, r.ErroEstimado)
+	}
+
+	// em 70 dimensões nem duas células por lado cabem nas amostras
+	var termos []string
+	limites = nil
+	for i := 1; i <= 70; i++ {
+		nome := fmt.Sprintf("x%d", i)
+		termos = append(termos, nome)
+		limites = append(limites, Limite{nome, "0", "1"})
+	}
+	soma := IntegralMultipla{Corpo: strings.Join(termos, " + "), Limites: limites}
+	r, err = MonteCarlo(soma, OpcoesMonteCarlo{Amostragem: AmostragemEstratificada, Amostras: 1 << 12, Semente: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-35) > 5*r.ErroEstimado || len(r.Avisos) != 1 {
+		t.Errorf("70 dimensões: esperado 35 com um aviso, obtido %v ± %v (%v)", r.Valor, r.ErroEstimado, r.Avisos)
+	}
+}
+
+func TestPesosNewtonCotes(t *testing.T) {
+	boole, err := PesosNewtonCotes(4, Fechada)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, esperado := range []float64{7, 32, 12, 32, 7} {
+		if math.Abs(boole[i]-esperado/90) > 1e-15 {
+			t.Errorf("peso %d de Boole: esperado %v, obtido %v", i, esperado/90, boole[i])
+		}
+	}
+
+	aberta, err := PesosNewtonCotes(2, Aberta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, esperado := range []float64{2.0 / 3, -1.0 / 3, 2.0 / 3} {
+		if math.Abs(aberta[i]-esperado) > 1e-15 {
+			t.Errorf("peso %d da fórmula aberta de 3 pontos: esperado %v, obtido %v", i, esperado, aberta[i])
+		}
+	}
+
+	// a fórmula fechada de ordem par n integra polinômios de grau n+1 exatamente
+	r, err := NewtonCotes(Expressao{Corpo: "x**7", Parametro: "x", A: 0, B: 2}, 6, Fechada, NewCriterioParada(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-32) > 1e-10 || len(r.Avisos) != 0 {
+		t.Errorf("x^7 em [0, 2]: esperado 32, obtido %v (%v)", r.Valor, r.Avisos)
+	}
+
+	r, err = NewtonCotes(Expressao{Corpo: "sin(x)", Parametro: "x", A: 0, B: math.Pi}, 10, Fechada, NewCriterioParada(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-2) > 1e-9 || len(r.Avisos) == 0 {
+		t.Errorf("ordem 10: esperado 2 com aviso de pesos negativos, obtido %v (%v)", r.Valor, r.Avisos)
+	}
+
+	r, err = NewtonCotes(Expressao{Corpo: "1/x**0.5", Parametro: "x", A: 0, B: 1}, 3, Aberta, NewCriterioParada(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-2) > 0.05 {
+		t.Errorf("a fórmula aberta não avalia os extremos e deveria se aproximar de 2, obteve %v", r.Valor)
+	}
+}
+
+func TestClenshawCurtis(t *testing.T) {
+	casos := []struct {
+		integral Expressao
+		esperado float64
+	}{
+		{Expressao{Corpo: "e**x", Parametro: "x", A: 0, B: 1}, math.E - 1},
+		{Expressao{Corpo: "1/(1 + 25*x**2)", Parametro: "x", A: -1, B: 1}, 0.4 * math.Atan(5)},
+		{Expressao{Corpo: "sin(x)", Parametro: "x", A: 0, B: 2 * math.Pi}, 0},
+	}
+	for _, c := range casos {
+		r, err := ClenshawCurtis(c.integral, NewCriterioParada(12))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Convergiu || math.Abs(r.Valor-c.esperado) > 1e-11 {
+			t.Errorf("%s: esperado %v, obtido %v ± %v (%v)", c.integral.Corpo, c.esperado, r.Valor, r.ErroEstimado, r.Avisos)
+		}
+	}
+
+	// integrandos inteiros convergem com poucos pontos, todos reaproveitados
+	r, err := ClenshawCurtis(Expressao{Corpo: "e**x", Parametro: "x", A: 0, B: 1}, NewCriterioParada(12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Avaliacoes > 33 {
+		t.Errorf("e^x deveria precisar de no máximo 33 avaliações, usou %d", r.Avaliacoes)
+	}
+}
+
+func TestReaproveitamentoAvaliacoes(t *testing.T) {
+	integral := Expressao{Corpo: "e**x", Parametro: "x", A: 0, B: 1}
+	regras := []struct {
+		nome  string
+		ordem int
+		regra func(Expressao, CriterioParada) (ResultadoIntegral, error)
+	}{
+		{"trapézios", 1, RegraDosTrapeziosRepetida},
+		{"Simpson 1/3", 2, RegraDeSimpson13Repetida},
+		{"Simpson 3/8", 3, RegraDeSimpson38Repetida},
+	}
+	for _, c := range regras {
+		r, err := c.regra(integral, NewCriterioParada(8))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !r.Convergiu || math.Abs(r.Valor-(math.E-1)) > 1e-7 {
+			t.Errorf("%s: esperado e-1, obtido %v", c.nome, r.Valor)
+		}
+		// cada ponto da malha final é avaliado uma única vez
+		if pontos := r.Subintervalos*c.ordem + 1; r.Avaliacoes != pontos {
+			t.Errorf("%s: %d avaliações para uma malha de %d pontos", c.nome, r.Avaliacoes, pontos)
+		}
+	}
+}
+
+func TestToleranciaRelativa(t *testing.T) {
+	// o erro absoluto de uma integral tão grande nunca chega a 1e-8
+	integral := Expressao{Corpo: "x**2", Parametro: "x", A: 0, B: 1000}
+	r, err := RegraDeSimpson13Repetida(integral, NewCriterioParada(8))
+	if err != nil || !r.Convergiu || math.Abs(r.Valor-1e9/3) > 1e-8*1e9 {
+		t.Errorf("esperado 1e9/3, obtido %+v (%v)", r, err)
+	}
+}
+
+// integrandoCaro tem custo de avaliação alto o bastante para que o
+// paralelismo compense.
+var integrandoCaro = Expressao{
+	Corpo:     "sin(x)**2*cos(3*x) + logn(x+2)*abs(tan(x/3)) + e**(-(x**2))",
+	Parametro: "x",
+	A:         0,
+	B:         3,
+}
+
+func BenchmarkNewtonCotesSequencial(b *testing.B) {
+	var r ResultadoIntegral
+	for i := 0; i < b.N; i++ {
+		r, _ = NewtonCotesParalela(integrandoCaro, 1, Fechada, NewCriterioParada(8), 1)
+	}
+	b.ReportMetric(float64(r.Avaliacoes), "avaliacoes/op")
+}
+
+func BenchmarkNewtonCotesParalela(b *testing.B) {
+	var r ResultadoIntegral
+	for i := 0; i < b.N; i++ {
+		r, _ = NewtonCotesParalela(integrandoCaro, 1, Fechada, NewCriterioParada(8), 0)
+	}
+	b.ReportMetric(float64(r.Avaliacoes), "avaliacoes/op")
+}
+
+func TestNewtonCotesParalela(t *testing.T) {
+	for _, formula := range []FormulaNewtonCotes{Fechada, Aberta} {
+		sequencial, err := NewtonCotesParalela(integrandoCaro, 1, formula, NewCriterioParada(7), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		paralela, err := NewtonCotesParalela(integrandoCaro, 1, formula, NewCriterioParada(7), 4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// cada valor ocupa a mesma posição, então a soma é idêntica
+		if paralela.Valor != sequencial.Valor || paralela.Avaliacoes != sequencial.Avaliacoes {
+			t.Errorf("fórmula %q: paralela %+v difere da sequencial %+v", formula, paralela, sequencial)
+		}
+		if sequencial.Avaliacoes < 4*loteParalelo {
+			t.Errorf("fórmula %q: só %d avaliações, poucas para exercitar os trabalhadores", formula, sequencial.Avaliacoes)
+		}
+	}
+
+	singular := Expressao{Corpo: "1/(1-x)", Parametro: "x", A: 0, B: 1}
+	if _, err := NewtonCotesParalela(singular, 2, Fechada, NewCriterioParada(5), 4); err == nil || !strings.Contains(err.Error(), "singular") {
+		t.Errorf("a versão paralela deveria acusar a singularidade em x = 1, devolveu %v", err)
+	}
+	if _, err := NewtonCotesParalela(integrandoCaro, 2, Fechada, NewCriterioParada(5), -1); err == nil {
+		t.Error("um número negativo de trabalhadores deveria ser recusado")
+	}
+}
+
+func TestAvaliacaoParalelaErro(t *testing.T) {
+	nos := make([]float64, 10*loteParalelo)
+	for i := range nos {
+		nos[i] = float64(i)
+	}
+	falha := func(x float64) (float64, error) {
+		if x == 300 {
+			return 0.0, errors.New("falha em x = 300")
+		}
+		return x, nil
+	}
+	fs := []func(float64) (float64, error){falha, falha, falha}
+	err := avaliacaoParalela(fs)(context.Background(), nos, make([]float64, len(nos)))
+	if err == nil || err.Error() != "falha em x = 300" {
+		t.Errorf("esperado o erro do trabalhador, obtido %v", err)
+	}
+
+	// com o prazo vencido, parte dos nós fica sem valor
+	vencido, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	identidade := func(x float64) (float64, error) { return x, nil }
+	fs = []func(float64) (float64, error){identidade, identidade, identidade}
+	if err := avaliacaoParalela(fs)(vencido, nos, make([]float64, len(nos))); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("esperado o prazo esgotado, obtido %v", err)
+	}
+}
+
+func TestIntegracaoPorPartes(t *testing.T) {
+	// um salto em 1 e outro em 2: a integral em [0, 3] é 1/2 + 3 = 3.5
+	degraus := Expressao{Corpo: "piecewise(x < 1, x, x < 2, 3, 0)", Parametro: "x", A: 0, B: 3}
+	metodos := map[string]func(Expressao, CriterioParada) (ResultadoIntegral, error){
+		"trapézios":       RegraDosTrapeziosRepetida,
+		"simpson 1/3":     RegraDeSimpson13Repetida,
+		"gauss-kronrod":   GaussKronrod,
+		"clenshaw-curtis": ClenshawCurtis,
+		"tanh-sinh":       TanhSinh,
+	}
+	for nome, metodo := range metodos {
+		for _, sentido := range []float64{1, -1} {
+			integral := degraus
+			if sentido < 0 {
+				integral.A, integral.B = integral.B, integral.A
+			}
+			r, err := metodo(integral, NewCriterioParada(8))
+			if err != nil {
+				t.Errorf("%s: %v", nome, err)
+				continue
+			}
+			if math.Abs(r.Valor-3.5*sentido) > 1e-7 || len(r.PontosDeQuebra) != 2 {
+				t.Errorf("%s: esperado %v com dois pontos de quebra, obtido %+v", nome, 3.5*sentido, r)
+			}
+		}
+	}
+
+	// um imposto progressivo é contínuo, mas tem bicos nas faixas
+	imposto := Expressao{Corpo: "if(x < 0.3, 0, if(x < 0.7, 0.1*(x - 0.3), 0.04 + 0.3*(x - 0.7)))", Parametro: "x", A: 0, B: 1}
+	r, err := GaussKronrod(imposto, NewCriterioParada(10))
+	if esperado := 0.1*0.08 + 0.04*0.3 + 0.3*0.045; err != nil || math.Abs(r.Valor-esperado) > 1e-12 || r.Subintervalos != 3 {
+		t.Errorf("imposto: esperado %v em três subintervalos, obtido %+v (%v)", esperado, r, err)
+	}
+
+	// o limite de avaliações vale para a soma dos trechos: cada trecho dos
+	// degraus custa 15 avaliações, e o terceiro fica sem nenhuma
+	r, err = GaussKronrod(degraus, CriterioParada{PassoAbsoluto: 1e-8, MaxAvaliacoes: 30})
+	if err != nil || r.Convergiu || r.Avaliacoes != 30 || len(r.Avisos) != 1 {
+		t.Errorf("degraus com 30 avaliações: esperado parar no terceiro trecho, obtido %+v (%v)", r, err)
+	}
+}
@@ -0,0 +1,54 @@
+package metodos
+
+import "math"
+
+// intervaloFinito reescreve a integral de f em [a, b], com a ou b infinitos,
+// como uma integral em (0, 1] pelas substituições do QAGI do QUADPACK:
+//
+//	[a, ∞)   x = a + (1-t)/t
+//	(-∞, b]  x = b - (1-t)/t
+//	(-∞, ∞)  x = ±(1-t)/t, somando os dois ramos
+//
+// com dx = dt/t². O integrando transformado nunca é avaliado em t = 0, o que
+// as quadraturas gaussianas já garantem. Quando a > b, o sinal é invertido.
+// Para intervalos finitos ou vazios, como [∞, ∞], f, a e b são devolvidos sem
+// alteração, e a integral em [a, a] é zero.
+func intervaloFinito(f func(float64) (float64, error), a, b float64) (func(float64) (float64, error), float64, float64) {
+	if a == b || !math.IsInf(a, 0) && !math.IsInf(b, 0) {
+		return f, a, b
+	}
+
+	sinal := 1.0
+	if a > b {
+		a, b = b, a
+		sinal = -1
+	}
+
+	var x func(t float64) float64
+	ramos := 1
+	switch {
+	case math.IsInf(a, -1) && math.IsInf(b, 1):
+		x = func(t float64) float64 { return (1 - t) / t }
+		ramos = 2
+	case math.IsInf(b, 1):
+		x = func(t float64) float64 { return a + (1-t)/t }
+	default:
+		x = func(t float64) float64 { return b - (1-t)/t }
+	}
+
+	g := func(t float64) (float64, error) {
+		soma, err := f(x(t))
+		if err != nil {
+			return 0.0, err
+		}
+		if ramos == 2 {
+			outro, err := f(-x(t))
+			if err != nil {
+				return 0.0, err
+			}
+			soma += outro
+		}
+		return sinal * soma / (t * t), nil
+	}
+	return g, 0, 1
+}
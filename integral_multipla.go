@@ -0,0 +1,210 @@
+package metodos
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// IntegralMultipla é uma integral iterada de Corpo, uma expressão em várias
+// variáveis. Limites vai da variável mais externa à mais interna, e os limites
+// de cada variável podem depender das variáveis externas a ela:
+//
+//	∫_0^1 ∫_0^x x*y dy dx  →  Limites: [{x, "0", "1"}, {y, "0", "x"}]
+//
+// Constantes e Definicoes valem no corpo e nos limites, como em Expressao.
+type IntegralMultipla struct {
+	Corpo      string             `json:"corpo"`
+	Limites    []Limite           `json:"limites"`
+	Constantes map[string]float64 `json:"constantes,omitempty"`
+	Definicoes []string           `json:"definicoes,omitempty"`
+}
+
+// expressao devolve texto como uma Expressao com as constantes e as
+// definições da integral.
+func (i IntegralMultipla) expressao(texto string) Expressao {
+	return Expressao{Corpo: texto, Constantes: i.Constantes, Definicoes: i.Definicoes}
+}
+
+// Limite dá o intervalo de integração de uma variável. A e B são expressões
+// nas variáveis externas, ou "inf" e "-inf" para limites infinitos.
+type Limite struct {
+	Variavel string `json:"variavel"`
+	A        string `json:"a"`
+	B        string `json:"b"`
+}
+
+// RegraMultipla escolhe a regra unidimensional aplicada em cada nível.
+type RegraMultipla string
+
+const (
+	RegraGaussKronrod RegraMultipla = ""
+	RegraSimpson      RegraMultipla = "simpson"
+)
+
+// IntegrarMultipla calcula a integral aplicando a regra unidimensional
+// escolhida a cada variável, da mais interna para a mais externa. O critério
+// vale para o nível externo, e cada nível interno recebe tolerâncias dez
+// vezes menores, até o limite do arredondamento, para que o erro das
+// integrais internas não domine o total.
+func IntegrarMultipla(integral IntegralMultipla, regra RegraMultipla, criterio CriterioParada) (ResultadoIntegral, error) {
+	if err := criterio.validarIntegracao(); err != nil {
+		return ResultadoIntegral{}, err
+	}
+	switch regra {
+	case RegraGaussKronrod, RegraSimpson:
+	default:
+		return ResultadoIntegral{}, errors.Errorf("regra de integração desconhecida: %s", regra)
+	}
+	if len(integral.Limites) == 0 {
+		return ResultadoIntegral{}, errors.New("a integral múltipla precisa de pelo menos uma variável")
+	}
+
+	m := integradorMultiplo{regra: regra}
+	vistas := make(map[string]bool, len(integral.Limites))
+	for _, l := range integral.Limites {
+		if l.Variavel == "" || vistas[l.Variavel] {
+			return ResultadoIntegral{}, errors.Errorf("variável de integração vazia ou repetida: %q", l.Variavel)
+		}
+		vistas[l.Variavel] = true
+		a, err := newLimiteAvaliavel(integral.expressao(l.A), m.variaveis)
+		if err != nil {
+			return ResultadoIntegral{}, errors.Wrapf(err, "limite inferior de %s", l.Variavel)
+		}
+		b, err := newLimiteAvaliavel(integral.expressao(l.B), m.variaveis)
+		if err != nil {
+			return ResultadoIntegral{}, errors.Wrapf(err, "limite superior de %s", l.Variavel)
+		}
+		m.variaveis = append(m.variaveis, l.Variavel)
+		m.limites = append(m.limites, [2]limiteAvaliavel{a, b})
+	}
+	corpo, err := compilarExpressao(integral.expressao(integral.Corpo), m.variaveis)
+	if err != nil {
+		return ResultadoIntegral{}, err
+	}
+	m.corpo = corpo
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	params := make(map[string]interface{}, len(m.variaveis)+2)
+	resultado, err := m.integrar(ctx, 0, params, criterio)
+	resultado.Avaliacoes = corpo.Avaliacoes()
+	return resultado, err
+}
+
+// limiteAvaliavel é um limite fixo, possivelmente infinito, ou uma expressão.
+type limiteAvaliavel struct {
+	valor float64
+	expr  *ExpressaoAvaliavel
+}
+
+// newLimiteAvaliavel compila o limite, que só pode depender das variáveis
+// externas.
+func newLimiteAvaliavel(limite Expressao, externas []string) (limiteAvaliavel, error) {
+	switch limite.Corpo {
+	case "inf", "+inf":
+		return limiteAvaliavel{valor: math.Inf(1)}, nil
+	case "-inf":
+		return limiteAvaliavel{valor: math.Inf(-1)}, nil
+	}
+	expr, err := compilarExpressao(limite, append([]string{}, externas...))
+	if err != nil {
+		return limiteAvaliavel{}, err
+	}
+	return limiteAvaliavel{expr: &expr}, nil
+}
+
+func (l limiteAvaliavel) avaliar(params map[string]interface{}) (float64, error) {
+	if l.expr == nil {
+		return l.valor, nil
+	}
+	return l.expr.Avaliar(params)
+}
+
+// pesosSimpson13 são os pesos da regra de Simpson 1/3 em [0, 1].
+var pesosSimpson13 = []float64{1.0 / 6, 4.0 / 6, 1.0 / 6}
+
+type integradorMultiplo struct {
+	corpo     ExpressaoAvaliavel
+	regra     RegraMultipla
+	variaveis []string
+	limites   [][2]limiteAvaliavel
+}
+
+// toleranciaInterna aperta em dez vezes a tolerância das integrais internas,
+// mas sem descer abaixo de 50·ε, que o arredondamento não deixa atingir.
+func toleranciaInterna(tol float64) float64 {
+	return math.Max(tol/10, math.Min(tol, 50*epsilonMaquina))
+}
+
+// integrar calcula a integral a partir do nível dado, com as variáveis dos
+// níveis externos já fixadas em params.
+func (m integradorMultiplo) integrar(ctx context.Context, nivel int, params map[string]interface{}, criterio CriterioParada) (ResultadoIntegral, error) {
+	var resultado ResultadoIntegral
+	a, err := m.limites[nivel][0].avaliar(params)
+	if err != nil {
+		return resultado, err
+	}
+	b, err := m.limites[nivel][1].avaliar(params)
+	if err != nil {
+		return resultado, err
+	}
+
+	variavel := m.variaveis[nivel]
+	interno := criterio
+	interno.PassoAbsoluto = toleranciaInterna(criterio.PassoAbsoluto)
+	interno.PassoRelativo = toleranciaInterna(criterio.PassoRelativo)
+	// maior erro estimado das integrais internas, em módulo
+	erroInterno := 0.0
+	internasConvergiram := true
+	avisos := make(map[string]bool)
+
+	f := func(x float64) (float64, error) {
+		params[variavel] = x
+		if nivel == len(m.variaveis)-1 {
+			return m.corpo.Avaliar(params)
+		}
+		r, err := m.integrar(ctx, nivel+1, params, interno)
+		if err != nil {
+			return 0.0, err
+		}
+		erroInterno = math.Max(erroInterno, r.ErroEstimado)
+		internasConvergiram = internasConvergiram && r.Convergiu
+		for _, aviso := range r.Avisos {
+			avisos[aviso] = true
+		}
+		return r.Valor, nil
+	}
+
+	switch m.regra {
+	case RegraSimpson:
+		if math.IsInf(a, 0) || math.IsInf(b, 0) {
+			return resultado, errors.Errorf("a regra de Simpson não aceita limites infinitos em %s; use Gauss-Kronrod", variavel)
+		}
+		resultado, err = newtonCotesComposta(ctx, avaliacaoSequencial(f), a, b, pesosSimpson13, Fechada, criterio)
+	default:
+		g, ta, tb := intervaloFinito(f, a, b)
+		resultado, err = gaussKronrod(ctx, g, ta, tb, criterio)
+	}
+	if err != nil {
+		return resultado, err
+	}
+
+	// o erro das integrais internas se acumula ao longo do intervalo externo
+	if !math.IsInf(a, 0) && !math.IsInf(b, 0) {
+		resultado.ErroEstimado += erroInterno * math.Abs(b-a)
+	}
+	resultado.Convergiu = resultado.Convergiu && internasConvergiram
+	internos := make([]string, 0, len(avisos))
+	for aviso := range avisos {
+		internos = append(internos, aviso)
+	}
+	sort.Strings(internos)
+	resultado.Avisos = append(resultado.Avisos, internos...)
+	return resultado, nil
+}
@@ -0,0 +1,297 @@
+package metodos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Intervalo é um subintervalo de [A, B] que contém uma raiz isolada.
+type Intervalo struct {
+	A float64 `json:"a"`
+	B float64 `json:"b"`
+	// TrocaDeSinal é falso quando f não muda de sinal no intervalo mas |f|
+	// tem um mínimo próximo de zero, indício de uma raiz de multiplicidade par.
+	TrocaDeSinal bool `json:"trocaDeSinal"`
+}
+
+// ResultadoIsolamento são os intervalos de IsolarRaizes, em ordem crescente,
+// e os avisos do isolamento.
+type ResultadoIsolamento struct {
+	Intervalos []Intervalo `json:"result"`
+	Avisos     []string    `json:"avisos,omitempty"`
+}
+
+// IsolarRaizes amostra f em n subintervalos de [A, B] e devolve os que
+// contêm uma troca de sinal ou um mínimo de |f| abaixo da tolerância de
+// resíduo do critério. As amostras em que f não está definida são ignoradas,
+// com um aviso.
+func IsolarRaizes(funcao Expressao, n int, criterio CriterioParada) (ResultadoIsolamento, error) {
+	if err := criterio.validar(); err != nil {
+		return ResultadoIsolamento{}, err
+	}
+	expr, err := NewExpressaoAvaliavel(funcao)
+	if err != nil {
+		return ResultadoIsolamento{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	intervalos, avisos, err := isolarRaizes(ctx, expr, n, criterio)
+	return ResultadoIsolamento{Intervalos: intervalos, Avisos: avisos}, err
+}
+
+// ResultadoRaizes são as raízes encontradas por BuscarRaizes, em ordem
+// crescente, e os avisos da busca.
+type ResultadoRaizes struct {
+	Raizes []float64 `json:"result"`
+	Avisos []string  `json:"avisos,omitempty"`
+}
+
+// BuscarRaizes isola as raízes de f em [A, B] com n amostras e refina cada
+// uma com o método de intervalo indicado ("bissecao", "posicaofalsa" ou uma
+// das variantes "illinois", "pegasus" e "andersonbjorck"), que tem para si os
+// limites do critério. As raízes cujo refinamento esgota um limite são
+// devolvidas com um aviso; os intervalos em que f deixa de estar definida
+// durante o refinamento são descartados, também com um aviso.
+func BuscarRaizes(funcao Expressao, metodo string, n int, criterio CriterioParada) (ResultadoRaizes, error) {
+	if err := criterio.validar(); err != nil {
+		return ResultadoRaizes{}, err
+	}
+	expr, err := NewExpressaoAvaliavel(funcao)
+	if err != nil {
+		return ResultadoRaizes{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	return buscarRaizes(ctx, expr, metodo, n, criterio)
+}
+
+func buscarRaizes(ctx context.Context, funcao ExpressaoAvaliavel, metodo string, n int, criterio CriterioParada) (ResultadoRaizes, error) {
+	var resultado ResultadoRaizes
+	precisaoEsperada := criterio.toleranciaPasso()
+	intervalos, avisos, err := isolarRaizes(ctx, funcao, n, criterio)
+	if err != nil {
+		return resultado, err
+	}
+	resultado.Avisos = avisos
+
+	raizes := make([]float64, 0, len(intervalos))
+	for _, intervalo := range intervalos {
+		var raiz float64
+		switch {
+		case intervalo.A == intervalo.B:
+			raiz = intervalo.A
+		case intervalo.TrocaDeSinal:
+			var refinamento Resultado
+			refinamento, err = refinarRaiz(ctx, funcao.comIntervalo(intervalo.A, intervalo.B).novaContagem(), metodo, criterio)
+			raiz = refinamento.Valor
+			if err == nil && !refinamento.Convergiu {
+				for _, aviso := range refinamento.Avisos {
+					resultado.Avisos = append(resultado.Avisos, fmt.Sprintf("raiz %g em [%g, %g]: %s", raiz, intervalo.A, intervalo.B, aviso))
+				}
+			}
+		default:
+			raiz, _, err = minimoAbsoluto(ctx, funcao, intervalo.A, intervalo.B, precisaoEsperada)
+		}
+		if foraDoDominio(err) {
+			resultado.Avisos = append(resultado.Avisos, fmt.Sprintf("intervalo [%g, %g] descartado: %v", intervalo.A, intervalo.B, err))
+			continue
+		}
+		if err != nil {
+			return resultado, err
+		}
+		raizes = append(raizes, raiz)
+	}
+
+	resultado.Raizes = raizesDistintas(raizes, precisaoEsperada)
+	return resultado, nil
+}
+
+// raizesDistintas ordena as raízes e descarta as que distam menos que tol da
+// anterior.
+func raizesDistintas(raizes []float64, tol float64) []float64 {
+	sort.Float64s(raizes)
+	distintas := raizes[:0]
+	for _, raiz := range raizes {
+		if len(distintas) > 0 && math.Abs(raiz-distintas[len(distintas)-1]) < tol {
+			continue
+		}
+		distintas = append(distintas, raiz)
+	}
+	return distintas
+}
+
+func refinarRaiz(ctx context.Context, funcao ExpressaoAvaliavel, metodo string, criterio CriterioParada) (Resultado, error) {
+	switch metodo {
+	case "", "bissecao":
+		return bisseccao(ctx, funcao, criterio)
+	case "posicaofalsa", "illinois", "pegasus", "andersonbjorck":
+		variante := VariantePosicaoFalsa(strings.TrimPrefix(metodo, "posicaofalsa"))
+		return posicaoFalsa(ctx, funcao, variante, criterio)
+	default:
+		return Resultado{}, errors.Errorf("método de intervalo desconhecido: %s", metodo)
+	}
+}
+
+// isolarRaizes devolve os intervalos e os avisos sobre as amostras e os
+// mínimos ignorados por f não estar definida neles.
+func isolarRaizes(ctx context.Context, funcao ExpressaoAvaliavel, n int, criterio CriterioParada) ([]Intervalo, []string, error) {
+	if n < 1 {
+		return nil, nil, errors.New("o número de amostras deve ser positivo")
+	}
+	a := funcao.expr.A
+	b := funcao.expr.B
+	if !(a < b) {
+		return nil, nil, errors.New("o intervalo [a, b] é inválido")
+	}
+
+	step := (b - a) / float64(n)
+	xs := make([]float64, n+1)
+	fs := make([]float64, n+1)
+	indefinidas := make([]error, n+1)
+	for i := 0; i <= n; i++ {
+		xs[i] = a + float64(i)*step
+		if i == n {
+			xs[i] = b
+		}
+		r, err := funcao.avaliarEm(xs[i])
+		if foraDoDominio(err) {
+			// NaN não passa em nenhum dos testes de sinal abaixo
+			r, indefinidas[i], err = math.NaN(), err, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		fs[i] = r
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		default:
+			continue
+		}
+	}
+	avisos := avisosIndefinidas(xs, indefinidas)
+
+	var intervalos []Intervalo
+	for i := 0; i < n; i++ {
+		switch {
+		case fs[i] == 0:
+			intervalos = append(intervalos, Intervalo{xs[i], xs[i], true})
+		case fs[i]*fs[i+1] < 0:
+			intervalos = append(intervalos, Intervalo{xs[i], xs[i+1], true})
+		}
+	}
+	if fs[n] == 0 {
+		intervalos = append(intervalos, Intervalo{b, b, true})
+	}
+
+	// mínimos locais de |f| sem troca de sinal; as amostras dos extremos só
+	// são comparadas com a vizinha, e o mínimo é procurado na célula delas
+	for i := 0; i <= n; i++ {
+		anterior, seguinte := i-1, i+1
+		if i == 0 {
+			anterior = i
+		}
+		if i == n {
+			seguinte = i
+		}
+		if algumNaN(fs[anterior:seguinte+1]) || fs[anterior]*fs[i] <= 0 || fs[i]*fs[seguinte] <= 0 {
+			continue
+		}
+		if math.Abs(fs[i]) > math.Abs(fs[anterior]) || math.Abs(fs[i]) > math.Abs(fs[seguinte]) {
+			continue
+		}
+		_, fx, err := minimoAbsoluto(ctx, funcao, xs[anterior], xs[seguinte], criterio.toleranciaPasso())
+		if foraDoDominio(err) {
+			avisos = append(avisos, fmt.Sprintf("mínimo de |f| em [%g, %g] descartado: %v", xs[anterior], xs[seguinte], err))
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if math.Abs(fx) < criterio.toleranciaResiduo() {
+			intervalos = append(intervalos, Intervalo{xs[anterior], xs[seguinte], false})
+		}
+	}
+
+	sort.Slice(intervalos, func(i, j int) bool { return intervalos[i].A < intervalos[j].A })
+	return intervalos, avisos, nil
+}
+
+// avisosIndefinidas descreve as amostras ignoradas por estarem fora do
+// domínio de f, juntando as consecutivas num só aviso.
+func avisosIndefinidas(xs []float64, erros []error) []string {
+	var avisos []string
+	for i := 0; i < len(xs); i++ {
+		if erros[i] == nil {
+			continue
+		}
+		j := i
+		for j+1 < len(xs) && erros[j+1] != nil {
+			j++
+		}
+		if i == j {
+			avisos = append(avisos, fmt.Sprintf("amostra em x = %g ignorada: %v", xs[i], erros[i]))
+		} else {
+			avisos = append(avisos, fmt.Sprintf("%d amostras em [%g, %g] ignoradas: %v", j-i+1, xs[i], xs[j], erros[i]))
+		}
+		i = j
+	}
+	return avisos
+}
+
+// minimoAbsoluto procura o mínimo de |f| em [a, b] pela razão áurea. A
+// precisão não desce abaixo do espaçamento dos float64 perto de a e b, onde
+// os pontos internos deixariam de se mover.
+func minimoAbsoluto(ctx context.Context, funcao ExpressaoAvaliavel, a, b, precisao float64) (float64, float64, error) {
+	razao := (math.Sqrt(5) - 1) / 2
+	precisao = math.Max(precisao, 4*epsilonMaquina*math.Max(math.Abs(a), math.Abs(b)))
+
+	c := b - razao*(b-a)
+	d := a + razao*(b-a)
+	fc, err := funcao.avaliarEm(c)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+	fd, err := funcao.avaliarEm(d)
+	if err != nil {
+		return 0.0, 0.0, err
+	}
+
+	for b-a > precisao {
+		if math.Abs(fc) < math.Abs(fd) {
+			b, d, fd = d, c, fc
+			c = b - razao*(b-a)
+			fc, err = funcao.avaliarEm(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + razao*(b-a)
+			fd, err = funcao.avaliarEm(d)
+		}
+		if err != nil {
+			return 0.0, 0.0, err
+		}
+		select {
+		case <-ctx.Done():
+			return 0.0, 0.0, ctx.Err()
+		default:
+			continue
+		}
+	}
+
+	if math.Abs(fc) < math.Abs(fd) {
+		return c, fc, nil
+	}
+	return d, fd, nil
+}
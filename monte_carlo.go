@@ -0,0 +1,363 @@
+package metodos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// AmostragemMonteCarlo escolhe como MonteCarlo sorteia os pontos.
+type AmostragemMonteCarlo string
+
+const (
+	// AmostragemSimples sorteia pontos pseudoaleatórios uniformes.
+	AmostragemSimples AmostragemMonteCarlo = ""
+	// AmostragemEstratificada divide o cubo unitário em células iguais e
+	// sorteia o mesmo número de pontos em cada uma.
+	AmostragemEstratificada AmostragemMonteCarlo = "estratificada"
+	// AmostragemSobol e AmostragemHalton usam sequências de baixa
+	// discrepância com deslocamentos aleatórios (quasi-Monte Carlo).
+	AmostragemSobol  AmostragemMonteCarlo = "sobol"
+	AmostragemHalton AmostragemMonteCarlo = "halton"
+)
+
+// OpcoesMonteCarlo configura MonteCarlo. Os valores zero usam os padrões.
+type OpcoesMonteCarlo struct {
+	Amostragem AmostragemMonteCarlo `json:"amostragem"`
+	// Amostras é o total de avaliações do integrando, 65536 por padrão
+	Amostras int `json:"amostras"`
+	// Semente torna o resultado reprodutível, qualquer que seja o número de trabalhadores
+	Semente int64 `json:"semente"`
+	// Trabalhadores é o número de goroutines, runtime.NumCPU() por padrão
+	Trabalhadores int `json:"trabalhadores"`
+}
+
+const (
+	amostrasMonteCarlo = 1 << 16
+	// blocosMonteCarlo é o número fixo de blocos independentes em que as
+	// amostras são divididas, para que a semente determine o resultado
+	blocosMonteCarlo = 16
+)
+
+// MonteCarlo estima a integral pela média do integrando em pontos sorteados,
+// o que vale a pena em dimensões altas, onde as regras iteradas exigiriam
+// pontos demais. Os limites podem depender das variáveis externas, como em
+// IntegrarMultipla, mas precisam ser finitos. ErroEstimado é o erro padrão
+// da estimativa; nas sequências quasi-aleatórias ele vem da dispersão entre
+// os blocos, cada um com seu deslocamento aleatório.
+func MonteCarlo(integral IntegralMultipla, opcoes OpcoesMonteCarlo) (ResultadoIntegral, error) {
+	if len(integral.Limites) == 0 {
+		return ResultadoIntegral{}, errors.New("a integral precisa de pelo menos uma variável")
+	}
+	if opcoes.Amostras < 0 || opcoes.Trabalhadores < 0 {
+		return ResultadoIntegral{}, errors.New("o número de amostras e de trabalhadores não pode ser negativo")
+	}
+	if opcoes.Amostras == 0 {
+		opcoes.Amostras = amostrasMonteCarlo
+	}
+	if opcoes.Amostras < 2*blocosMonteCarlo {
+		return ResultadoIntegral{}, errors.Errorf("são necessárias pelo menos %d amostras", 2*blocosMonteCarlo)
+	}
+	if opcoes.Trabalhadores == 0 {
+		opcoes.Trabalhadores = runtime.NumCPU()
+	}
+
+	dimensao := len(integral.Limites)
+	var seq sequencia
+	switch opcoes.Amostragem {
+	case AmostragemSimples, AmostragemEstratificada:
+	case AmostragemSobol:
+		s, err := newSobol(dimensao)
+		if err != nil {
+			return ResultadoIntegral{}, err
+		}
+		seq = s
+	case AmostragemHalton:
+		seq = newHalton(dimensao)
+	default:
+		return ResultadoIntegral{}, errors.Errorf("amostragem desconhecida: %s", opcoes.Amostragem)
+	}
+
+	// cada trabalhador compila a própria cópia das expressões, que não podem
+	// ser avaliadas por várias goroutines ao mesmo tempo
+	integrandos := make([]integrandoCubo, opcoes.Trabalhadores)
+	for i := range integrandos {
+		var err error
+		if integrandos[i], err = newIntegrandoCubo(integral); err != nil {
+			return ResultadoIntegral{}, err
+		}
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	por := opcoes.Amostras / blocosMonteCarlo
+	lado, celulas := 1, 1
+	if opcoes.Amostragem == AmostragemEstratificada {
+		// o maior lado com lado^d células de pelo menos duas amostras cada,
+		// para que a variância de cada célula possa ser estimada; a conta em
+		// ponto flutuante não estoura nas dimensões altas
+		for math.Pow(float64(lado+1), float64(dimensao)) <= float64(opcoes.Amostras/2) {
+			lado++
+		}
+		celulas = potencia(lado, dimensao)
+	}
+	porCelula := opcoes.Amostras / celulas
+
+	parciais := make([]parcialMonteCarlo, blocosMonteCarlo)
+	blocos := make(chan int)
+	erros := make(chan error, opcoes.Trabalhadores)
+	var wg sync.WaitGroup
+	for t := 0; t < opcoes.Trabalhadores; t++ {
+		wg.Add(1)
+		go func(f integrandoCubo) {
+			defer wg.Done()
+			for bloco := range blocos {
+				rng := rand.New(rand.NewSource(opcoes.Semente + int64(bloco)))
+				var p parcialMonteCarlo
+				var err error
+				switch {
+				case seq != nil:
+					p, err = blocoQuasiAleatorio(ctx, f, seq, rng, por)
+				case celulas > 1:
+					p, err = blocoEstratificado(ctx, f, rng, bloco, lado, celulas, porCelula)
+				default:
+					p, err = blocoSimples(ctx, f, rng, por)
+				}
+				if err != nil {
+					erros <- err
+					cancel()
+					return
+				}
+				parciais[bloco] = p
+			}
+		}(integrandos[t])
+	}
+	for bloco := 0; bloco < blocosMonteCarlo; bloco++ {
+		select {
+		case blocos <- bloco:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(blocos)
+	wg.Wait()
+
+	var resultado ResultadoIntegral
+	for _, f := range integrandos {
+		resultado.Avaliacoes += f.corpo.Avaliacoes()
+	}
+	select {
+	case err := <-erros:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			return resultado, err
+		}
+	default:
+	}
+	if ctx.Err() != nil {
+		return resultado, errors.New("tempo esgotado antes de completar as amostras")
+	}
+
+	switch {
+	case seq != nil:
+		// cada bloco é uma estimativa independente da integral
+		media, variancia := 0.0, 0.0
+		for _, p := range parciais {
+			media += p.soma / blocosMonteCarlo
+		}
+		for _, p := range parciais {
+			variancia += (p.soma - media) * (p.soma - media) / (blocosMonteCarlo - 1)
+		}
+		resultado.Valor = media
+		resultado.ErroEstimado = math.Sqrt(variancia / blocosMonteCarlo)
+	case celulas > 1:
+		for _, p := range parciais {
+			resultado.Valor += p.soma
+			resultado.ErroEstimado += p.somaQuadrados
+		}
+		resultado.ErroEstimado = math.Sqrt(resultado.ErroEstimado)
+	default:
+		n, soma, somaQuadrados := 0, 0.0, 0.0
+		for _, p := range parciais {
+			n += p.n
+			soma += p.soma
+			somaQuadrados += p.somaQuadrados
+		}
+		media := soma / float64(n)
+		variancia := (somaQuadrados/float64(n) - media*media) * float64(n) / float64(n-1)
+		resultado.Valor = media
+		resultado.ErroEstimado = math.Sqrt(math.Max(variancia, 0) / float64(n))
+	}
+	if opcoes.Amostragem == AmostragemEstratificada && celulas == 1 {
+		resultado.Avisos = append(resultado.Avisos, fmt.Sprintf(
+			"%d amostras não bastam para estratificar %d dimensões; foi usada a amostragem simples", opcoes.Amostras, dimensao))
+	}
+	resultado.Convergiu = true
+	return resultado, nil
+}
+
+// parcialMonteCarlo é o resultado de um bloco; o significado dos campos
+// depende da amostragem.
+type parcialMonteCarlo struct {
+	soma, somaQuadrados float64
+	n                   int
+}
+
+func potencia(base, expoente int) int {
+	r := 1
+	for i := 0; i < expoente; i++ {
+		r *= base
+	}
+	return r
+}
+
+// blocoSimples acumula a soma e a soma dos quadrados de f em pontos uniformes.
+func blocoSimples(ctx context.Context, f integrandoCubo, rng *rand.Rand, n int) (parcialMonteCarlo, error) {
+	var p parcialMonteCarlo
+	u := make([]float64, f.dimensao())
+	for i := 0; i < n; i++ {
+		for d := range u {
+			u[d] = rng.Float64()
+		}
+		v, err := f.avaliar(u)
+		if err != nil {
+			return p, err
+		}
+		p.soma += v
+		p.somaQuadrados += v * v
+		p.n++
+		if i%1024 == 0 && ctx.Err() != nil {
+			return p, ctx.Err()
+		}
+	}
+	return p, nil
+}
+
+// blocoEstratificado trata as células bloco, bloco+blocosMonteCarlo, ... e
+// devolve a contribuição delas para a integral e para a variância.
+func blocoEstratificado(ctx context.Context, f integrandoCubo, rng *rand.Rand, bloco, lado, celulas, porCelula int) (parcialMonteCarlo, error) {
+	var p parcialMonteCarlo
+	dimensao := f.dimensao()
+	volume := 1 / float64(celulas)
+	u := make([]float64, dimensao)
+	for c := bloco; c < celulas; c += blocosMonteCarlo {
+		soma, somaQuadrados := 0.0, 0.0
+		for i := 0; i < porCelula; i++ {
+			indice := c
+			for d := range u {
+				u[d] = (float64(indice%lado) + rng.Float64()) / float64(lado)
+				indice /= lado
+			}
+			v, err := f.avaliar(u)
+			if err != nil {
+				return p, err
+			}
+			soma += v
+			somaQuadrados += v * v
+		}
+		media := soma / float64(porCelula)
+		variancia := (somaQuadrados/float64(porCelula) - media*media) * float64(porCelula) / float64(porCelula-1)
+		p.soma += volume * media
+		p.somaQuadrados += volume * volume * math.Max(variancia, 0) / float64(porCelula)
+		p.n += porCelula
+		if ctx.Err() != nil {
+			return p, ctx.Err()
+		}
+	}
+	return p, nil
+}
+
+// blocoQuasiAleatorio estima a integral com n pontos da sequência, todos
+// deslocados módulo 1 pelo mesmo vetor aleatório (rotação de Cranley-Patterson).
+func blocoQuasiAleatorio(ctx context.Context, f integrandoCubo, seq sequencia, rng *rand.Rand, n int) (parcialMonteCarlo, error) {
+	var p parcialMonteCarlo
+	dimensao := f.dimensao()
+	deslocamento := make([]float64, dimensao)
+	for d := range deslocamento {
+		deslocamento[d] = rng.Float64()
+	}
+	u := make([]float64, dimensao)
+	for i := 0; i < n; i++ {
+		seq.ponto(uint32(i), u)
+		for d := range u {
+			u[d] += deslocamento[d]
+			if u[d] >= 1 {
+				u[d]--
+			}
+		}
+		v, err := f.avaliar(u)
+		if err != nil {
+			return p, err
+		}
+		p.soma += v
+		if i%1024 == 0 && ctx.Err() != nil {
+			return p, ctx.Err()
+		}
+	}
+	p.soma /= float64(n)
+	p.n = n
+	return p, nil
+}
+
+// integrandoCubo leva o cubo unitário à região de integração, variável por
+// variável, e devolve o integrando multiplicado pelo jacobiano da mudança.
+type integrandoCubo struct {
+	corpo     ExpressaoAvaliavel
+	variaveis []string
+	limites   [][2]limiteAvaliavel
+	params    map[string]interface{}
+}
+
+func newIntegrandoCubo(integral IntegralMultipla) (integrandoCubo, error) {
+	f := integrandoCubo{params: make(map[string]interface{}, len(integral.Limites)+2)}
+	for _, l := range integral.Limites {
+		a, err := newLimiteAvaliavel(integral.expressao(l.A), f.variaveis)
+		if err != nil {
+			return integrandoCubo{}, errors.Wrapf(err, "limite inferior de %s", l.Variavel)
+		}
+		b, err := newLimiteAvaliavel(integral.expressao(l.B), f.variaveis)
+		if err != nil {
+			return integrandoCubo{}, errors.Wrapf(err, "limite superior de %s", l.Variavel)
+		}
+		f.variaveis = append(f.variaveis, l.Variavel)
+		f.limites = append(f.limites, [2]limiteAvaliavel{a, b})
+	}
+	corpo, err := compilarExpressao(integral.expressao(integral.Corpo), f.variaveis)
+	if err != nil {
+		return integrandoCubo{}, err
+	}
+	f.corpo = corpo
+	return f, nil
+}
+
+func (f integrandoCubo) dimensao() int { return len(f.variaveis) }
+
+func (f integrandoCubo) avaliar(u []float64) (float64, error) {
+	jacobiano := 1.0
+	for d, variavel := range f.variaveis {
+		a, err := f.limites[d][0].avaliar(f.params)
+		if err != nil {
+			return 0.0, err
+		}
+		b, err := f.limites[d][1].avaliar(f.params)
+		if err != nil {
+			return 0.0, err
+		}
+		if math.IsInf(a, 0) || math.IsInf(b, 0) {
+			return 0.0, errors.Errorf("Monte Carlo não aceita limites infinitos em %s", variavel)
+		}
+		f.params[variavel] = a + (b-a)*u[d]
+		jacobiano *= b - a
+	}
+	v, err := f.corpo.Avaliar(f.params)
+	return v * jacobiano, err
+}
@@ -0,0 +1,227 @@
+package metodos
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// EstimarMultiplicidade estima a multiplicidade m de uma raiz já encontrada.
+// Perto de uma raiz de multiplicidade m vale f(x)/f'(x) ≈ (x - raiz)/m, então
+// m ≈ (x - raiz)·f'(x)/f(x); a média dos dois lados da raiz cancela o erro de
+// primeira ordem da aproximação da raiz.
+func EstimarMultiplicidade(funcao, derivada Expressao, raiz float64) (int, error) {
+	expr, err := NewExpressaoAvaliavel(funcao)
+	if err != nil {
+		return 0, err
+	}
+	derivadaExpr, err := NewExpressaoAvaliavel(mesmoParametro(derivada, funcao))
+	if err != nil {
+		return 0, err
+	}
+
+	h := 1e-3 * math.Max(1, math.Abs(raiz))
+	soma := 0.0
+	for _, x := range []float64{raiz - h, raiz + h} {
+		fx, err := expr.avaliarEm(x)
+		if err != nil {
+			return 0, err
+		}
+		dx, err := derivadaExpr.avaliarEm(x)
+		if err != nil {
+			return 0, err
+		}
+		if fx == 0 {
+			return 0, errors.Errorf("f se anula em x = %g, perto demais da raiz para estimar a multiplicidade", x)
+		}
+		soma += (x - raiz) * dx / fx
+	}
+	m := int(math.Round(soma / 2))
+	if m < 1 || math.IsNaN(soma) {
+		return 0, errors.Errorf("não foi possível estimar a multiplicidade da raiz %g", raiz)
+	}
+	return m, nil
+}
+
+// NewtonMultiplicidade é o método de Newton com passo x - m·f(x)/f'(x), que
+// recupera a convergência quadrática em raízes de multiplicidade m conhecida.
+func NewtonMultiplicidade(funcao, derivada Expressao, m int, x0 float64, criterio CriterioParada) (Resultado, error) {
+	if m < 1 {
+		return Resultado{}, errors.New("a multiplicidade deve ser pelo menos 1")
+	}
+	if err := criterio.validar(); err != nil {
+		return Resultado{}, err
+	}
+	expr, err := NewExpressaoAvaliavel(funcao)
+	if err != nil {
+		return Resultado{}, err
+	}
+
+	derivadaExpr, err := NewExpressaoAvaliavel(mesmoParametro(derivada, funcao))
+	if err != nil {
+		return Resultado{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	return newtonMultiplicidade(ctx, expr, derivadaExpr, m, x0, criterio)
+}
+
+// NewtonModificado aplica o método de Newton a u(x) = f(x)/f'(x), que só tem
+// raízes simples, sem precisar conhecer a multiplicidade. O passo de Newton
+// em u envolve u', por isso a segunda derivada de f é necessária.
+func NewtonModificado(funcao, derivada, segundaDerivada Expressao, x0 float64, criterio CriterioParada) (Resultado, error) {
+	if err := criterio.validar(); err != nil {
+		return Resultado{}, err
+	}
+	expr, err := NewExpressaoAvaliavel(funcao)
+	if err != nil {
+		return Resultado{}, err
+	}
+
+	derivadaExpr, err := NewExpressaoAvaliavel(mesmoParametro(derivada, funcao))
+	if err != nil {
+		return Resultado{}, err
+	}
+
+	segundaDerivadaExpr, err := NewExpressaoAvaliavel(mesmoParametro(segundaDerivada, funcao))
+	if err != nil {
+		return Resultado{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	return newtonModificado(ctx, expr, derivadaExpr, segundaDerivadaExpr, x0, criterio)
+}
+
+func newtonMultiplicidade(ctx context.Context, funcao, derivada ExpressaoAvaliavel, m int, x0 float64, criterio CriterioParada) (Resultado, error) {
+	resultado := Resultado{Multiplicidade: m}
+	x := x0
+	fx, err := funcao.avaliarEm(x)
+	if err != nil {
+		return resultado, err
+	}
+	if fx == 0 {
+		resultado.Valor = x
+		resultado.Convergiu = true
+		return resultado, nil
+	}
+	for i := 1; ; i++ {
+		dx, err := derivada.avaliarEm(x)
+		if err != nil {
+			return resultado, err
+		}
+		if dx == 0 {
+			resultado.Valor = x
+			return resultado, errors.Errorf("derivada nula em x = %g", x)
+		}
+
+		anterior := x
+		x -= float64(m) * fx / dx
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return resultado, errors.New("o método de Newton com multiplicidade divergiu")
+		}
+		// o resíduo do critério é o do novo x, reaproveitado na próxima iteração
+		if fx, err = funcao.avaliarEm(x); err != nil {
+			return resultado, err
+		}
+
+		avaliacoes := funcao.Avaliacoes() + derivada.Avaliacoes()
+		if criterio.parar(&resultado, estadoIteracao{i, avaliacoes, x, anterior, fx}) {
+			return resultado, nil
+		}
+		select {
+		case <-ctx.Done():
+			return resultado, ctx.Err()
+		default:
+			continue
+		}
+	}
+}
+
+func newtonModificado(ctx context.Context, funcao, derivada, segundaDerivada ExpressaoAvaliavel, x0 float64, criterio CriterioParada) (Resultado, error) {
+	var resultado Resultado
+	x := x0
+	// u'(x) = 1 - f·f''/f'² tende a 1/m, o que dá a multiplicidade de graça
+	multiplicidade := 0.0
+	fx, err := funcao.avaliarEm(x)
+	if err != nil {
+		return resultado, err
+	}
+	if fx == 0 {
+		resultado.Valor = x
+		resultado.Convergiu = true
+		return resultado, nil
+	}
+	for i := 1; ; i++ {
+		dx, err := derivada.avaliarEm(x)
+		if err != nil {
+			return resultado, err
+		}
+		d2x, err := segundaDerivada.avaliarEm(x)
+		if err != nil {
+			return resultado, err
+		}
+
+		denominador := dx*dx - fx*d2x
+		if denominador == 0 {
+			resultado.Valor = x
+			return resultado, errors.New("o denominador do método de Newton modificado se anulou")
+		}
+		if dx != 0 {
+			multiplicidade = dx * dx / denominador
+		}
+
+		anterior := x
+		x -= fx * dx / denominador
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return resultado, errors.New("o método de Newton modificado divergiu")
+		}
+		// o resíduo do critério é o do novo x, reaproveitado na próxima iteração
+		if fx, err = funcao.avaliarEm(x); err != nil {
+			return resultado, err
+		}
+
+		avaliacoes := funcao.Avaliacoes() + derivada.Avaliacoes() + segundaDerivada.Avaliacoes()
+		if criterio.parar(&resultado, estadoIteracao{i, avaliacoes, x, anterior, fx}) {
+			if resultado.Convergiu {
+				resultado.registrarMultiplicidade(multiplicidade)
+			}
+			return resultado, nil
+		}
+		select {
+		case <-ctx.Done():
+			return resultado, ctx.Err()
+		default:
+			continue
+		}
+	}
+}
+
+// multiplicidadePelaTaxa estima a multiplicidade pela razão entre os dois
+// últimos passos de Newton, que tende a (m-1)/m numa raiz de multiplicidade m
+// e a zero numa raiz simples. Devolve zero quando não há passos suficientes.
+func multiplicidadePelaTaxa(passos []float64) float64 {
+	if len(passos) < 2 || passos[len(passos)-2] == 0 {
+		return 0
+	}
+	r := math.Abs(passos[len(passos)-1] / passos[len(passos)-2])
+	if r >= 1 {
+		return 0
+	}
+	return 1 / (1 - r)
+}
+
+// registrarMultiplicidade arredonda a estimativa, ignorando estimativas inválidas.
+func (r *Resultado) registrarMultiplicidade(estimativa float64) {
+	if estimativa < 0.5 || math.IsNaN(estimativa) || math.IsInf(estimativa, 0) {
+		return
+	}
+	r.Multiplicidade = int(math.Round(estimativa))
+}
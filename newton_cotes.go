@@ -0,0 +1,300 @@
+package metodos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// FormulaNewtonCotes escolhe entre as fórmulas fechadas, que usam os
+// extremos de cada painel, e as abertas, que só usam pontos interiores.
+type FormulaNewtonCotes string
+
+const (
+	Fechada FormulaNewtonCotes = ""
+	Aberta  FormulaNewtonCotes = "aberta"
+)
+
+// maxOrdemNewtonCotes limita a ordem; acima dela os pesos oscilam demais
+// para servirem a qualquer propósito prático.
+const maxOrdemNewtonCotes = 40
+
+// PesosNewtonCotes devolve os pesos da fórmula de Newton-Cotes de ordem n no
+// intervalo [0, 1]. A fórmula fechada usa os n+1 nós j/n, j = 0..n; a aberta
+// usa os n+1 nós (j+1)/(n+2). Os pesos são calculados exatamente, em números
+// racionais, impondo que a fórmula integre 1, x, ..., x^n sem erro.
+func PesosNewtonCotes(n int, formula FormulaNewtonCotes) ([]float64, error) {
+	racionais, err := pesosNewtonCotes(n, formula)
+	if err != nil {
+		return nil, err
+	}
+	pesos := make([]float64, len(racionais))
+	for i, p := range racionais {
+		pesos[i], _ = p.Float64()
+	}
+	return pesos, nil
+}
+
+type chaveNewtonCotes struct {
+	n       int
+	formula FormulaNewtonCotes
+}
+
+// os pesos só dependem da ordem e da fórmula, então são calculados uma vez
+var (
+	cachePesosNewtonCotes   = make(map[chaveNewtonCotes][]*big.Rat)
+	cachePesosNewtonCotesMu sync.Mutex
+)
+
+func pesosNewtonCotes(n int, formula FormulaNewtonCotes) ([]*big.Rat, error) {
+	switch formula {
+	case Fechada:
+		if n < 1 {
+			return nil, errors.New("a fórmula fechada de Newton-Cotes precisa de ordem pelo menos 1")
+		}
+	case Aberta:
+		if n < 0 {
+			return nil, errors.New("a fórmula aberta de Newton-Cotes precisa de ordem pelo menos 0")
+		}
+	default:
+		return nil, errors.Errorf("fórmula de Newton-Cotes desconhecida: %s", formula)
+	}
+	if n > maxOrdemNewtonCotes {
+		return nil, errors.Errorf("a ordem máxima de Newton-Cotes é %d", maxOrdemNewtonCotes)
+	}
+
+	cachePesosNewtonCotesMu.Lock()
+	defer cachePesosNewtonCotesMu.Unlock()
+	chave := chaveNewtonCotes{n, formula}
+	if pesos, ok := cachePesosNewtonCotes[chave]; ok {
+		return pesos, nil
+	}
+
+	nos := make([]*big.Rat, n+1)
+	for j := range nos {
+		if formula == Fechada {
+			nos[j] = big.NewRat(int64(j), int64(n))
+		} else {
+			nos[j] = big.NewRat(int64(j+1), int64(n+2))
+		}
+	}
+
+	// sistema de Vandermonde: Σ_j w_j·x_j^k = 1/(k+1), k = 0..n
+	m := n + 1
+	a := make([][]*big.Rat, m)
+	for k := range a {
+		a[k] = make([]*big.Rat, m+1)
+		for j, x := range nos {
+			a[k][j] = potenciaRacional(x, k)
+		}
+		a[k][m] = big.NewRat(1, int64(k+1))
+	}
+	pesos, err := resolverRacional(a)
+	if err != nil {
+		return nil, err
+	}
+	cachePesosNewtonCotes[chave] = pesos
+	return pesos, nil
+}
+
+func potenciaRacional(x *big.Rat, k int) *big.Rat {
+	r := big.NewRat(1, 1)
+	for i := 0; i < k; i++ {
+		r.Mul(r, x)
+	}
+	return r
+}
+
+// resolverRacional resolve o sistema aumentado a por eliminação de Gauss.
+func resolverRacional(a [][]*big.Rat) ([]*big.Rat, error) {
+	m := len(a)
+	for col := 0; col < m; col++ {
+		pivo := col
+		for pivo < m && a[pivo][col].Sign() == 0 {
+			pivo++
+		}
+		if pivo == m {
+			return nil, errors.New("sistema singular ao calcular os pesos de Newton-Cotes")
+		}
+		a[col], a[pivo] = a[pivo], a[col]
+		for lin := 0; lin < m; lin++ {
+			if lin == col || a[lin][col].Sign() == 0 {
+				continue
+			}
+			fator := new(big.Rat).Quo(a[lin][col], a[col][col])
+			for j := col; j <= m; j++ {
+				a[lin][j].Sub(a[lin][j], new(big.Rat).Mul(fator, a[col][j]))
+			}
+		}
+	}
+	x := make([]*big.Rat, m)
+	for i := range x {
+		x[i] = new(big.Rat).Quo(a[i][m], a[i][i])
+	}
+	return x, nil
+}
+
+// NewtonCotes aplica a fórmula de ordem n de forma composta, dobrando o
+// número de painéis até o critério ser satisfeito. Na fórmula fechada os
+// pontos de um refinamento são reaproveitados no seguinte. Fórmulas com pesos
+// negativos, como as fechadas a partir da ordem 8 e a maioria das abertas,
+// geram um aviso, pois amplificam os erros de arredondamento.
+func NewtonCotes(integral Expressao, n int, formula FormulaNewtonCotes, criterio CriterioParada) (ResultadoIntegral, error) {
+	return NewtonCotesParalela(integral, n, formula, criterio, 1)
+}
+
+// NewtonCotesParalela é NewtonCotes com os nós de cada refinamento avaliados
+// por várias goroutines, o que compensa para integrandos caros ou malhas
+// grandes. Trabalhadores igual a zero usa runtime.NumCPU(). O resultado é o
+// mesmo da versão sequencial.
+func NewtonCotesParalela(integral Expressao, n int, formula FormulaNewtonCotes, criterio CriterioParada, trabalhadores int) (ResultadoIntegral, error) {
+	if err := criterio.validarIntegracao(); err != nil {
+		return ResultadoIntegral{}, err
+	}
+	if err := integral.limitesFinitos(); err != nil {
+		return ResultadoIntegral{}, err
+	}
+	if trabalhadores < 0 {
+		return ResultadoIntegral{}, errors.New("o número de trabalhadores não pode ser negativo")
+	}
+	if trabalhadores == 0 {
+		trabalhadores = runtime.NumCPU()
+	}
+	pesos, err := PesosNewtonCotes(n, formula)
+	if err != nil {
+		return ResultadoIntegral{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	// o mapa de parâmetros e o contador de uma expressão não podem ser
+	// usados por várias goroutines, então cada trabalhador tem a sua
+	exprs := make([]ExpressaoAvaliavel, trabalhadores)
+	fs := make([]func(float64) (float64, error), trabalhadores)
+	for i := range exprs {
+		if exprs[i], err = NewExpressaoAvaliavel(integral); err != nil {
+			return ResultadoIntegral{}, err
+		}
+		fs[i] = exprs[i].avaliarEm
+	}
+
+	quebras := exprs[0].pontosDeQuebra(integral.A, integral.B)
+	resultado, err := porPartes(quebras, integral.A, integral.B, criterio, func(t trecho, criterio CriterioParada) (ResultadoIntegral, error) {
+		noTrecho := make([]func(float64) (float64, error), len(fs))
+		for i, f := range fs {
+			noTrecho[i] = t.interior(f)
+		}
+		return newtonCotesComposta(ctx, avaliacaoParalela(noTrecho), t.a, t.b, pesos, formula, criterio)
+	})
+	for _, p := range pesos {
+		if p < 0 {
+			resultado.Avisos = append(resultado.Avisos, fmt.Sprintf(
+				"a fórmula de Newton-Cotes de ordem %d tem pesos negativos e pode amplificar erros de arredondamento", n))
+			break
+		}
+	}
+	resultado.Avaliacoes = 0
+	for i := range exprs {
+		resultado.Avaliacoes += exprs[i].Avaliacoes()
+	}
+	return resultado, err
+}
+
+func newtonCotesComposta(ctx context.Context, avaliar avaliadorNos, a, b float64, pesos []float64, formula FormulaNewtonCotes, criterio CriterioParada) (ResultadoIntegral, error) {
+	var resultado ResultadoIntegral
+	n := len(pesos) - 1
+
+	// valores guarda f na malha fechada de paineis·n + 1 pontos
+	var valores []float64
+	aplicar := func(paineis int) (float64, error) {
+		h := (b - a) / float64(paineis)
+		soma := 0.0
+		if formula == Aberta {
+			nos := make([]float64, 0, paineis*(n+1))
+			for p := 0; p < paineis; p++ {
+				for j := range pesos {
+					nos = append(nos, a+h*(float64(p)+float64(j+1)/float64(n+2)))
+				}
+			}
+			fx := make([]float64, len(nos))
+			if err := avaliar(ctx, nos, fx); err != nil {
+				return 0.0, err
+			}
+			resultado.Avaliacoes += len(nos)
+			for i, v := range fx {
+				soma += pesos[i%(n+1)] * v
+			}
+			return soma * h, nil
+		}
+
+		// só os pontos de índice ímpar são novos, exceto no primeiro nível
+		pontos := paineis*n + 1
+		novos := make([]float64, pontos)
+		var indices []int
+		var nos []float64
+		for i := range novos {
+			if valores != nil && i%2 == 0 {
+				novos[i] = valores[i/2]
+				continue
+			}
+			indices = append(indices, i)
+			nos = append(nos, a+(b-a)*float64(i)/float64(pontos-1))
+		}
+		fx := make([]float64, len(nos))
+		if err := avaliar(ctx, nos, fx); err != nil {
+			return 0.0, err
+		}
+		resultado.Avaliacoes += len(nos)
+		for k, i := range indices {
+			if i == 0 || i == pontos-1 {
+				if err := verificarExtremo(nos[k], fx[k]); err != nil {
+					return 0.0, err
+				}
+			}
+			novos[i] = fx[k]
+		}
+		valores = novos
+		for p := 0; p < paineis; p++ {
+			for j, w := range pesos {
+				soma += w * valores[p*n+j]
+			}
+		}
+		return soma * h, nil
+	}
+
+	anterior, err := aplicar(1)
+	if err != nil {
+		return resultado, err
+	}
+	resultado.Valor = anterior
+	for i, paineis := 1, 2; ; i, paineis = i+1, paineis*2 {
+		r, err := aplicar(paineis)
+		if errors.Is(err, context.DeadlineExceeded) {
+			resultado.Avisos = append(resultado.Avisos, "tempo esgotado antes da convergência")
+			return resultado, nil
+		}
+		if err != nil {
+			return resultado, err
+		}
+		resultado.Valor, resultado.ErroEstimado, resultado.Subintervalos = r, math.Abs(r-anterior), paineis
+
+		estado := estadoIteracao{i, resultado.Avaliacoes, r, anterior, math.NaN()}
+		if criterio.convergiu(estado) {
+			resultado.Convergiu = true
+			return resultado, nil
+		}
+		if motivo := criterio.esgotado(estado); motivo != "" {
+			resultado.Avisos = append(resultado.Avisos, motivo)
+			return resultado, nil
+		}
+		anterior = r
+	}
+}
@@ -0,0 +1,55 @@
+package metodos
+
+import (
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// OrdemConvergencia descreve o comportamento assintótico |e_{k+1}| ≈ C·|e_k|^p
+// de um método iterativo: p = 1 é convergência linear com taxa C, p ≈ 1.618 é
+// a da secante e p = 2 é a do método de Newton em raízes simples.
+type OrdemConvergencia struct {
+	Ordem     float64 `json:"ordem"`
+	Constante float64 `json:"constante"`
+}
+
+// EstimarOrdem estima a ordem de convergência a partir das aproximações
+// sucessivas de um método, como Resultado.Historico. Os passos
+// d_k = |x_{k+1} - x_k| substituem os erros, desconhecidos, e a ordem vem de
+// p ≈ log(d_{k+1}/d_k) / log(d_k/d_{k-1}) nos últimos passos confiáveis.
+func EstimarOrdem(historico []float64) (OrdemConvergencia, error) {
+	if len(historico) < 4 {
+		return OrdemConvergencia{}, errors.New("são necessárias pelo menos quatro aproximações para estimar a ordem de convergência")
+	}
+	passos := make([]float64, len(historico)-1)
+	for i := range passos {
+		passos[i] = math.Abs(historico[i+1] - historico[i])
+	}
+	return ordemPelosPassos(passos, historico[len(historico)-1])
+}
+
+// ordemPelosPassos usa os três últimos passos acima do ruído de arredondamento
+// em torno de x. Passos menores que isso só refletem o erro de ponto flutuante.
+func ordemPelosPassos(passos []float64, x float64) (OrdemConvergencia, error) {
+	ruido := 1e-14 * math.Max(1, math.Abs(x))
+	fim := len(passos)
+	for fim > 0 && !(passos[fim-1] > ruido) {
+		fim--
+	}
+	if fim < 3 {
+		return OrdemConvergencia{}, errors.New("passos insuficientes para estimar a ordem de convergência")
+	}
+
+	d0, d1, d2 := passos[fim-3], passos[fim-2], passos[fim-1]
+	denominador := math.Log(d1 / d0)
+	if denominador == 0 {
+		return OrdemConvergencia{}, errors.New("passos estagnados, a ordem de convergência é indefinida")
+	}
+	p := math.Log(d2/d1) / denominador
+	c := d2 / math.Pow(d1, p)
+	if math.IsNaN(p) || math.IsInf(p, 0) || math.IsNaN(c) || math.IsInf(c, 0) {
+		return OrdemConvergencia{}, errors.New("não foi possível estimar a ordem de convergência")
+	}
+	return OrdemConvergencia{Ordem: p, Constante: c}, nil
+}
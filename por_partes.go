@@ -0,0 +1,181 @@
+package metodos
+
+import (
+	"fmt"
+	"math"
+)
+
+// Integrandos com comparações, if ou piecewise costumam ter saltos ou bicos
+// onde uma condição muda de valor, e as regras de integração perdem a ordem
+// quando um painel ou segmento atravessa um deles. Por isso GaussKronrod,
+// ClenshawCurtis, TanhSinh e as regras de Newton-Cotes procuram esses pontos
+// de quebra em [A, B] e integram cada trecho entre eles separadamente. Com
+// limites infinitos os pontos de quebra não são procurados.
+
+// amostrasQuebra é o número de subintervalos em que cada condição é
+// amostrada à procura de trocas de sinal.
+const amostrasQuebra = 256
+
+// condicoesDe devolve, para cada comparação e cada condição da árvore que não
+// é uma comparação, a função cujo sinal decide o seu valor: esquerda - direita
+// nas comparações e a própria condição nas demais.
+func condicoesDe(raiz no) []no {
+	var condicoes []no
+	percorrer(raiz, func(n no) {
+		switch n := n.(type) {
+		case binario:
+			if operadorComparacao(n.op) {
+				condicoes = append(condicoes, binario{'-', n.esquerda, n.direita})
+			}
+		case condicional:
+			if b, ok := n.condicao.(binario); !ok || !operadorComparacao(b.op) {
+				condicoes = append(condicoes, n.condicao)
+			}
+		}
+	})
+	return condicoes
+}
+
+// pontosDeQuebra devolve, de a para b, os pontos entre eles em que alguma
+// condição da expressão muda de valor. Cada condição é amostrada em
+// amostrasQuebra subintervalos e as trocas de sinal são refinadas por
+// bissecção até a precisão da máquina; duas trocas no mesmo subintervalo
+// passam despercebidas, assim como os pontos em que a condição não está
+// definida.
+func (e *ExpressaoAvaliavel) pontosDeQuebra(a, b float64) []float64 {
+	if a == b || math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return nil
+	}
+	inicio, fim := math.Min(a, b), math.Max(a, b)
+	var quebras []float64
+	for i := range e.condicoes {
+		g := &e.condicoes[i]
+		avaliar := func(x float64) float64 {
+			v, err := g.avaliarEm(x)
+			if err != nil {
+				return math.NaN()
+			}
+			return v
+		}
+		h := (fim - inicio) / amostrasQuebra
+		x0, g0 := inicio, avaliar(inicio)
+		for k := 1; k <= amostrasQuebra; k++ {
+			x1 := inicio + float64(k)*h
+			if k == amostrasQuebra {
+				x1 = fim
+			}
+			g1 := avaliar(x1)
+			switch {
+			case g1 == 0:
+				quebras = append(quebras, x1)
+			case g0*g1 < 0:
+				quebras = append(quebras, trocaDeSinal(avaliar, x0, x1, g0))
+			}
+			x0, g0 = x1, g1
+		}
+	}
+
+	tol := 1e-12 * (fim - inicio)
+	internas := quebras[:0]
+	for _, q := range raizesDistintas(quebras, tol) {
+		if q-inicio > tol && fim-q > tol {
+			internas = append(internas, q)
+		}
+	}
+	if a > b {
+		for i, j := 0, len(internas)-1; i < j; i, j = i+1, j-1 {
+			internas[i], internas[j] = internas[j], internas[i]
+		}
+	}
+	return internas
+}
+
+// trocaDeSinal localiza por bissecção, até restarem dois números de ponto
+// flutuante vizinhos, a troca de sinal de g em [a, b].
+func trocaDeSinal(g func(float64) float64, a, b, ga float64) float64 {
+	for {
+		m := a + (b-a)/2
+		if m <= a || m >= b {
+			return m
+		}
+		gm := g(m)
+		switch {
+		case gm == 0 || math.IsNaN(gm):
+			return m
+		case ga*gm < 0:
+			b = m
+		default:
+			a, ga = m, gm
+		}
+	}
+}
+
+// trecho é uma parte de [A, B] entre dois pontos de quebra consecutivos, ou
+// entre um deles e A ou B.
+type trecho struct {
+	a, b float64
+	// quebraA e quebraB dizem se a e b são pontos de quebra
+	quebraA, quebraB bool
+}
+
+// interior desloca ligeiramente para dentro do trecho as avaliações feitas
+// exatamente num ponto de quebra, para que as regras fechadas usem ali o
+// mesmo ramo do resto do trecho, e não o valor do outro lado do salto.
+func (t trecho) interior(f func(float64) (float64, error)) func(float64) (float64, error) {
+	if !t.quebraA && !t.quebraB {
+		return f
+	}
+	escala := math.Max(math.Abs(t.a), math.Abs(t.b))
+	desvio := 1e-12*(t.b-t.a) + math.Copysign(4*epsilonMaquina*escala, t.b-t.a)
+	return func(x float64) (float64, error) {
+		switch {
+		case x == t.a && t.quebraA:
+			x += desvio
+		case x == t.b && t.quebraB:
+			x -= desvio
+		}
+		return f(x)
+	}
+}
+
+// porPartes integra cada trecho de [a, b] entre os pontos de quebra com a
+// mesma regra e soma os resultados. A tolerância absoluta do critério é
+// repartida igualmente entre os trechos, para que a soma dos erros ainda a
+// respeite, e MaxAvaliacoes vale para a integral toda: cada trecho recebe o
+// que sobrou dos anteriores, e os trechos que não chegam a ter avaliações
+// ficam de fora, com um aviso.
+func porPartes(quebras []float64, a, b float64, criterio CriterioParada, integrar func(t trecho, criterio CriterioParada) (ResultadoIntegral, error)) (ResultadoIntegral, error) {
+	if len(quebras) == 0 {
+		return integrar(trecho{a: a, b: b}, criterio)
+	}
+	extremos := append(append([]float64{a}, quebras...), b)
+	porTrecho := criterio
+	porTrecho.PassoAbsoluto /= float64(len(extremos) - 1)
+	total := ResultadoIntegral{Convergiu: true, PontosDeQuebra: quebras}
+	for i := 0; i+1 < len(extremos); i++ {
+		if criterio.MaxAvaliacoes > 0 {
+			porTrecho.MaxAvaliacoes = criterio.MaxAvaliacoes - total.Avaliacoes
+			if porTrecho.MaxAvaliacoes <= 0 {
+				total.Convergiu = false
+				total.Avisos = append(total.Avisos, fmt.Sprintf(
+					"limite de %d avaliações atingido antes de integrar [%g, %g]", criterio.MaxAvaliacoes, extremos[i], b))
+				return total, nil
+			}
+		}
+		r, err := integrar(trecho{extremos[i], extremos[i+1], i > 0, i+2 < len(extremos)}, porTrecho)
+		total.Valor += r.Valor
+		total.ErroEstimado += r.ErroEstimado
+		total.Convergiu = total.Convergiu && r.Convergiu
+		total.Avaliacoes += r.Avaliacoes
+		total.Subintervalos += r.Subintervalos
+		for _, aviso := range r.Avisos {
+			if !contemNome(total.Avisos, aviso) {
+				total.Avisos = append(total.Avisos, aviso)
+			}
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
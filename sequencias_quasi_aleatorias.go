@@ -0,0 +1,115 @@
+package metodos
+
+import "github.com/pkg/errors"
+
+// sequencia gera pontos de baixa discrepância em [0, 1)^d.
+type sequencia interface {
+	// ponto preenche u com o i-ésimo ponto da sequência
+	ponto(i uint32, u []float64)
+}
+
+// polinomiosSobol guarda, a partir da segunda dimensão, o grau s e os
+// coeficientes internos a de um polinômio primitivo sobre GF(2), junto com os
+// números de direção iniciais m, como na tabela de Joe e Kuo.
+var polinomiosSobol = []struct {
+	s, a uint32
+	m    []uint32
+}{
+	{1, 0, []uint32{1}},
+	{2, 1, []uint32{1, 3}},
+	{3, 1, []uint32{1, 3, 1}},
+	{3, 2, []uint32{1, 1, 1}},
+	{4, 1, []uint32{1, 1, 3, 3}},
+	{4, 4, []uint32{1, 3, 5, 13}},
+	{5, 2, []uint32{1, 1, 5, 5, 17}},
+	{5, 4, []uint32{1, 1, 5, 5, 5}},
+	{5, 7, []uint32{1, 1, 7, 11, 19}},
+	{5, 11, []uint32{1, 1, 5, 1, 1}},
+	{5, 13, []uint32{1, 1, 1, 3, 11}},
+	{5, 14, []uint32{1, 3, 5, 5, 31}},
+	{6, 1, []uint32{1, 3, 3, 9, 7, 49}},
+	{6, 13, []uint32{1, 1, 1, 15, 21, 21}},
+	{6, 16, []uint32{1, 3, 1, 13, 27, 49}},
+}
+
+const bitsSobol = 32
+
+// sobol é a sequência de Sobol com direções de 32 bits.
+type sobol struct {
+	direcoes [][bitsSobol]uint32
+}
+
+func newSobol(dimensao int) (sobol, error) {
+	if dimensao > len(polinomiosSobol)+1 {
+		return sobol{}, errors.Errorf("a sequência de Sobol está disponível até a dimensão %d; use Halton", len(polinomiosSobol)+1)
+	}
+	s := sobol{direcoes: make([][bitsSobol]uint32, dimensao)}
+	// a primeira dimensão é a sequência de van der Corput na base 2
+	for k := 0; k < bitsSobol; k++ {
+		s.direcoes[0][k] = 1 << uint(bitsSobol-1-k)
+	}
+	for d := 1; d < dimensao; d++ {
+		p := polinomiosSobol[d-1]
+		v := &s.direcoes[d]
+		for k := uint32(0); k < p.s && k < bitsSobol; k++ {
+			v[k] = p.m[k] << (bitsSobol - 1 - k)
+		}
+		for k := p.s; k < bitsSobol; k++ {
+			v[k] = v[k-p.s] ^ (v[k-p.s] >> p.s)
+			for j := uint32(1); j < p.s; j++ {
+				if (p.a>>(p.s-1-j))&1 == 1 {
+					v[k] ^= v[k-j]
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+func (s sobol) ponto(i uint32, u []float64) {
+	for d := range u {
+		var x uint32
+		for k := 0; i>>uint(k) != 0; k++ {
+			if (i>>uint(k))&1 == 1 {
+				x ^= s.direcoes[d][k]
+			}
+		}
+		u[d] = float64(x) / (1 << bitsSobol)
+	}
+}
+
+// halton usa o inverso radical de i+1 na base do d-ésimo primo.
+type halton struct {
+	bases []uint32
+}
+
+func newHalton(dimensao int) halton {
+	h := halton{bases: make([]uint32, 0, dimensao)}
+	for n := uint32(2); len(h.bases) < dimensao; n++ {
+		primo := true
+		for _, p := range h.bases {
+			if p*p > n {
+				break
+			}
+			if n%p == 0 {
+				primo = false
+				break
+			}
+		}
+		if primo {
+			h.bases = append(h.bases, n)
+		}
+	}
+	return h
+}
+
+func (h halton) ponto(i uint32, u []float64) {
+	for d, base := range h.bases {
+		x, fator := 0.0, 1.0
+		for n := i + 1; n > 0; n /= base {
+			fator /= float64(base)
+			x += fator * float64(n%base)
+		}
+		u[d] = x
+	}
+}
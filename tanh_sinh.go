@@ -0,0 +1,182 @@
+package metodos
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// maxNiveisTanhSinh limita quantas vezes o passo da regra tanh-sinh é dividido.
+const maxNiveisTanhSinh = 12
+
+// TanhSinh integra pela quadratura tanh-sinh (dupla exponencial), que leva
+// [A, B] a toda a reta por x = c + h·tanh(π/2·senh t) e aplica a regra dos
+// trapézios em t, dividindo o passo ao meio a cada nível. Os pesos decaem tão
+// rápido perto dos extremos que singularidades algébricas e logarítmicas,
+// como 1/sqrt(x) ou logn(x) em [0, 1], são integradas quase à precisão da
+// máquina. A e B nunca são avaliados, e limites infinitos são aceitos.
+// Num extremo diferente de zero, porém, x só chega a ε·|extremo| dele, o que
+// limita a precisão nas singularidades mais fortes.
+//
+// O critério compara os valores de dois níveis sucessivos.
+func TanhSinh(integral Expressao, criterio CriterioParada) (ResultadoIntegral, error) {
+	if err := criterio.validarIntegracao(); err != nil {
+		return ResultadoIntegral{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	expr, err := NewExpressaoAvaliavel(integral)
+	if err != nil {
+		return ResultadoIntegral{}, err
+	}
+
+	quebras := expr.pontosDeQuebra(integral.A, integral.B)
+	resultado, err := porPartes(quebras, integral.A, integral.B, criterio, func(t trecho, criterio CriterioParada) (ResultadoIntegral, error) {
+		f, a, b := intervaloFinito(expr.avaliarEm, t.a, t.b)
+		return tanhSinh(ctx, f, a, b, criterio)
+	})
+	resultado.Avaliacoes = expr.Avaliacoes()
+	return resultado, err
+}
+
+// lado é o estado de um dos extremos na soma de um nível da regra tanh-sinh.
+type lado struct {
+	ativo bool
+	// ultimo é o último termo w·f(x) somado do lado
+	ultimo float64
+}
+
+func tanhSinh(ctx context.Context, f func(float64) (float64, error), a, b float64, criterio CriterioParada) (ResultadoIntegral, error) {
+	var resultado ResultadoIntegral
+	if a == b {
+		resultado.Convergiu = true
+		return resultado, nil
+	}
+	centro := (a + b) / 2
+	meio := (b - a) / 2
+
+	// termo devolve w·f(x) e desativa o lado ao chegar ao extremo ou quando f
+	// deixa de ser finita antes de o peso se anular. Se os termos do lado
+	// ainda não eram desprezíveis diante do maior termo já somado, f estourou
+	// numa singularidade que a integral não suporta, e o resultado não
+	// converge. Um erro de domínio colado ao extremo, em geral causado por
+	// arredondamento, também desativa o lado; longe dos extremos, ele
+	// interrompe a integração. Cada aviso é dado uma só vez por extremo.
+	avisados := make(map[float64]bool, 2)
+	avisar := func(borda float64, aviso string) {
+		if !avisados[borda] {
+			avisados[borda] = true
+			resultado.Avisos = append(resultado.Avisos, aviso)
+		}
+	}
+	maior, diverge := 0.0, false
+	termo := func(l *lado, x, borda, peso float64) (float64, error) {
+		if !l.ativo {
+			return 0.0, nil
+		}
+		if x == borda {
+			l.ativo = false
+			return 0.0, nil
+		}
+		fx, err := f(x)
+		if foraDoDominio(err) && math.Abs(x-borda) <= 1e-8*math.Abs(meio) {
+			l.ativo = false
+			avisar(borda, fmt.Sprintf("%v; os nós mais próximos do extremo foram ignorados", err))
+			return 0.0, nil
+		}
+		if err != nil {
+			return 0.0, err
+		}
+		resultado.Avaliacoes++
+		if math.IsInf(fx, 0) || math.IsNaN(fx) {
+			l.ativo = false
+			if math.Abs(l.ultimo) > 1e-8*maior {
+				diverge = true
+				avisar(borda, fmt.Sprintf("f vale %v em x = %g, perto de um extremo, sem que os termos da soma decaiam; a integral provavelmente diverge", fx, x))
+			}
+			return 0.0, nil
+		}
+		l.ultimo = peso * fx
+		maior = math.Max(maior, math.Abs(l.ultimo))
+		return l.ultimo, nil
+	}
+
+	// somaNivel soma os termos em ±t, com t = inicio, inicio+passo, ...,
+	// enquanto algum lado não chegou ao extremo. A distância ao extremo,
+	// 1 - tanh(u) = 1/(e^u·cosh u), é calculada diretamente para não perder
+	// precisão por cancelamento.
+	somaNivel := func(inicio, passo float64) (float64, error) {
+		soma := 0.0
+		ladoA, ladoB := lado{ativo: true}, lado{ativo: true}
+		for t := inicio; ladoA.ativo || ladoB.ativo; t += passo {
+			u := math.Pi / 2 * math.Sinh(t)
+			complemento := 1 / (math.Exp(u) * math.Cosh(u))
+			peso := math.Pi / 2 * math.Cosh(t) / (math.Cosh(u) * math.Cosh(u))
+			if complemento == 0 || peso == 0 {
+				break
+			}
+			emA, err := termo(&ladoA, a+meio*complemento, a, peso)
+			if err != nil {
+				return 0.0, err
+			}
+			emB, err := termo(&ladoB, b-meio*complemento, b, peso)
+			if err != nil {
+				return 0.0, err
+			}
+			soma += emA + emB
+		}
+		return soma, nil
+	}
+
+	fc, err := f(centro)
+	if err != nil {
+		return resultado, err
+	}
+	resultado.Avaliacoes++
+	soma, err := somaNivel(1, 1)
+	if err != nil {
+		return resultado, err
+	}
+	passo := 1.0
+	soma += math.Pi / 2 * fc
+	anterior := meio * passo * soma
+
+	for nivel := 1; ; nivel++ {
+		passo /= 2
+		novos, err := somaNivel(passo, 2*passo)
+		if err != nil {
+			return resultado, err
+		}
+		soma += novos
+		valor := meio * passo * soma
+
+		resultado.Valor = valor
+		resultado.ErroEstimado = math.Abs(valor - anterior)
+		estado := estadoIteracao{nivel, resultado.Avaliacoes, valor, anterior, math.NaN()}
+		if criterio.convergiu(estado) {
+			resultado.Convergiu = !diverge
+			return resultado, nil
+		}
+		if motivo := criterio.esgotado(estado); motivo != "" {
+			resultado.Avisos = append(resultado.Avisos, motivo)
+			return resultado, nil
+		}
+		if nivel == maxNiveisTanhSinh {
+			resultado.Avisos = append(resultado.Avisos, "limite de níveis da quadratura tanh-sinh atingido sem convergência")
+			return resultado, nil
+		}
+		anterior = valor
+
+		select {
+		case <-ctx.Done():
+			resultado.Avisos = append(resultado.Avisos, "tempo esgotado antes da convergência")
+			return resultado, nil
+		default:
+			continue
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package metodos
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// ErroExpressao descreve um erro numa expressão com a posição do trecho
+// culpado, para que a interface possa destacá-lo. Coluna conta caracteres a
+// partir de 1; Comprimento é zero quando o erro está no fim da expressão.
+// Definicao é a posição, a partir de 1, da definição do usuário em que está o
+// erro, ou zero quando ele está no corpo.
+type ErroExpressao struct {
+	Mensagem    string `json:"mensagem"`
+	Coluna      int    `json:"coluna"`
+	Comprimento int    `json:"comprimento"`
+	Sugestao    string `json:"sugestao,omitempty"`
+	Definicao   int    `json:"definicao,omitempty"`
+}
+
+func (e *ErroExpressao) Error() string {
+	texto := fmt.Sprintf("expressão inválida: %s na coluna %d", e.Mensagem, e.Coluna)
+	if e.Definicao > 0 {
+		texto += fmt.Sprintf(" da definição %d", e.Definicao)
+	}
+	if e.Sugestao != "" {
+		texto += fmt.Sprintf("; você quis dizer %q?", e.Sugestao)
+	}
+	return texto
+}
+
+// ValidarExpressao compila a expressão sem avaliá-la. Erros de sintaxe e
+// nomes desconhecidos voltam como *ErroExpressao; problemas que não impedem a
+// avaliação, como um parâmetro que não aparece no corpo, voltam como avisos.
+func ValidarExpressao(expr Expressao) ([]string, error) {
+	e, err := NewExpressaoAvaliavel(expr)
+	if err != nil {
+		return nil, err
+	}
+	var avisos []string
+	if expr.Parametro != "" && e.parametro < 0 {
+		avisos = append(avisos, fmt.Sprintf("o parâmetro %s não aparece na expressão, que é constante", expr.Parametro))
+	}
+	return avisos, nil
+}
+
+// validarVariaveis recusa os nomes da árvore que não são constantes nem
+// estão entre as variáveis conhecidas.
+func validarVariaveis(raiz no, variaveis []string) error {
+	var erro error
+	percorrer(raiz, func(n no) {
+		v, ok := n.(variavel)
+		if !ok || erro != nil {
+			return
+		}
+		if _, ok := constantes[v.nome]; ok {
+			return
+		}
+		for _, conhecida := range variaveis {
+			if v.nome == conhecida {
+				return
+			}
+		}
+		candidatos := append(append([]string{}, variaveis...), nomesConstantes()...)
+		mensagem := fmt.Sprintf("variável desconhecida %q", v.nome)
+		if nomeDeFuncao(v.nome) {
+			mensagem = fmt.Sprintf("a função %s precisa de um argumento entre parênteses", v.nome)
+			candidatos = nil
+		}
+		erro = &ErroExpressao{
+			Mensagem:    mensagem,
+			Coluna:      v.coluna,
+			Comprimento: len([]rune(v.nome)),
+			Sugestao:    maisParecido(v.nome, candidatos),
+		}
+	})
+	return erro
+}
+
+// validarConstantes recusa constantes do usuário com nomes que não poderiam
+// aparecer no corpo ou que já têm outro significado nele.
+func validarConstantes(valores map[string]float64, variaveis []string) error {
+	for _, nome := range nomesOrdenados(valores) {
+		switch {
+		case !identificador(nome):
+			return errors.Errorf("nome de constante inválido: %q", nome)
+		case nomeDeFuncao(nome):
+			return errors.Errorf("a constante %q tem o nome de uma função", nome)
+		case math.IsNaN(valores[nome]):
+			return errors.Errorf("a constante %q não pode ser NaN", nome)
+		}
+		if _, ok := constantes[nome]; ok {
+			return errors.Errorf("a constante %q já é predefinida", nome)
+		}
+		for _, v := range variaveis {
+			if v == nome {
+				return errors.Errorf("%q não pode ser ao mesmo tempo variável e constante", nome)
+			}
+		}
+	}
+	return nil
+}
+
+// identificador diz se nome seria lido como um único nome pelo analisador.
+func identificador(nome string) bool {
+	for i, c := range nome {
+		if !(unicode.IsLetter(c) || c == '_' || (i > 0 && unicode.IsDigit(c))) {
+			return false
+		}
+	}
+	return nome != ""
+}
+
+// percorrer visita os nós da árvore em pré-ordem.
+func percorrer(n no, visitar func(no)) {
+	visitar(n)
+	switch n := n.(type) {
+	case negacao:
+		percorrer(n.operando, visitar)
+	case binario:
+		percorrer(n.esquerda, visitar)
+		percorrer(n.direita, visitar)
+	case chamada:
+		for _, a := range n.argumentos {
+			percorrer(a, visitar)
+		}
+	case chamadaDefinida:
+		for _, a := range n.argumentos {
+			percorrer(a, visitar)
+		}
+	case condicional:
+		percorrer(n.condicao, visitar)
+		percorrer(n.entao, visitar)
+		if n.senao != nil {
+			percorrer(n.senao, visitar)
+		}
+	}
+}
+
+func nomesFuncoes() []string {
+	nomes := append([]string{}, formasCondicionais...)
+	for _, f := range funcoesMatematicas {
+		nomes = append(nomes, f.nome)
+	}
+	return nomes
+}
+
+// nomeDeFuncao diz se nome é de uma função ou forma condicional da
+// linguagem.
+func nomeDeFuncao(nome string) bool {
+	return indiceFuncao(nome) >= 0 || contemNome(formasCondicionais, nome)
+}
+
+func nomesConstantes() []string {
+	return nomesOrdenados(constantes)
+}
+
+func nomesOrdenados(valores map[string]float64) []string {
+	nomes := make([]string, 0, len(valores))
+	for nome := range valores {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	return nomes
+}
+
+// maisParecido devolve o candidato mais próximo de nome pela distância de
+// edição, ou "" se nenhum estiver perto o bastante para ser um erro de
+// digitação.
+func maisParecido(nome string, candidatos []string) string {
+	melhor, menor := "", 0
+	for _, c := range candidatos {
+		d := distanciaEdicao(nome, c)
+		if melhor == "" || d < menor {
+			melhor, menor = c, d
+		}
+	}
+	limite := 2
+	if n := len([]rune(nome)); n <= 3 {
+		limite = 1
+	}
+	if melhor == "" || menor > limite {
+		return ""
+	}
+	return melhor
+}
+
+// distanciaEdicao é a distância de Damerau-Levenshtein restrita entre a e b:
+// inserções, remoções, trocas e transposições de letras vizinhas custam 1.
+func distanciaEdicao(a, b string) int {
+	x, y := []rune(a), []rune(b)
+	d := make([][]int, len(x)+1)
+	for i := range d {
+		d[i] = make([]int, len(y)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		for j := 1; j <= len(y); j++ {
+			custo := 1
+			if x[i-1] == y[j-1] {
+				custo = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+custo)
+			if i > 1 && j > 1 && x[i-1] == y[j-2] && x[i-2] == y[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(x)][len(y)]
+}
@@ -2,233 +2,966 @@ package metodos
 
 import (
 	"context"
+	"fmt"
 	"math"
+	"math/cmplx"
 	"time"
 
 	"github.com/pkg/errors"
 )
 
-func Bisseccao(funcao Expressao, k int) (float64, error) {
+func Bisseccao(funcao Expressao, criterio CriterioParada) (Resultado, error) {
+	if err := criterio.validar(); err != nil {
+		return Resultado{}, err
+	}
 	expr, err := NewExpressaoAvaliavel(funcao)
 	if err != nil {
-		return 0.0, err
+		return Resultado{}, err
 	}
 
 	const timeOut = time.Second * 5
 	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
 	defer cancel()
 
-	precisaoEsperada := math.Pow10(-k)
+	return bisseccao(ctx, expr, criterio)
+}
+
+// VariantePosicaoFalsa seleciona a modificação da posição falsa que evita a
+// estagnação de um dos extremos em funções convexas.
+type VariantePosicaoFalsa string
+
+const (
+	PosicaoFalsaSimples VariantePosicaoFalsa = ""
+	Illinois            VariantePosicaoFalsa = "illinois"
+	Pegasus             VariantePosicaoFalsa = "pegasus"
+	AndersonBjorck      VariantePosicaoFalsa = "andersonbjorck"
+)
+
+func PosicaoFalsa(funcao Expressao, variante VariantePosicaoFalsa, criterio CriterioParada) (Resultado, error) {
+	if err := criterio.validar(); err != nil {
+		return Resultado{}, err
+	}
+	expr, err := NewExpressaoAvaliavel(funcao)
+	if err != nil {
+		return Resultado{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
 
-	n := math.Ceil((math.Log10(funcao.B-funcao.A) - math.Log10(precisaoEsperada)) / math.Log10(2))
-	return bisseccao(ctx, expr, int(n))
+	return posicaoFalsa(ctx, expr, variante, criterio)
 }
 
-func PosicaoFalsa(funcao Expressao, k int) (float64, error) {
+// NewtonRalphson parte de x0; ChuteInicial dá um ponto de partida padrão.
+func NewtonRalphson(funcao, derivada Expressao, x0 float64, criterio CriterioParada) (Resultado, error) {
+	if err := criterio.validar(); err != nil {
+		return Resultado{}, err
+	}
 	expr, err := NewExpressaoAvaliavel(funcao)
 	if err != nil {
-		return 0.0, err
+		return Resultado{}, err
+	}
+
+	derivadaExpr, err := NewExpressaoAvaliavel(mesmoParametro(derivada, funcao))
+	if err != nil {
+		return Resultado{}, err
 	}
 
 	const timeOut = time.Second * 5
 	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
 	defer cancel()
 
-	return posicaoFalsa(ctx, expr, k)
+	return newtonRalphson(ctx, expr, derivadaExpr, x0, criterio)
 }
 
-func NewtonRalphson(funcao, derivada Expressao, k int) (float64, error) {
+// Secante parte de x0 e x1; ChutesIniciais dá pontos de partida padrão.
+func Secante(funcao Expressao, x0, x1 float64, criterio CriterioParada) (Resultado, error) {
+	if err := criterio.validar(); err != nil {
+		return Resultado{}, err
+	}
 	expr, err := NewExpressaoAvaliavel(funcao)
 	if err != nil {
-		return 0.0, err
+		return Resultado{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	return secante(ctx, expr, x0, x1, criterio)
+}
+
+func Halley(funcao, derivada, segundaDerivada Expressao, x0 float64, criterio CriterioParada) (Resultado, error) {
+	if err := criterio.validar(); err != nil {
+		return Resultado{}, err
+	}
+	expr, err := NewExpressaoAvaliavel(funcao)
+	if err != nil {
+		return Resultado{}, err
 	}
 
-	derivadaExpr, err := NewExpressaoAvaliavel(derivada)
+	derivadaExpr, err := NewExpressaoAvaliavel(mesmoParametro(derivada, funcao))
 	if err != nil {
-		return 0.0, err
+		return Resultado{}, err
+	}
+
+	segundaDerivadaExpr, err := NewExpressaoAvaliavel(mesmoParametro(segundaDerivada, funcao))
+	if err != nil {
+		return Resultado{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	return halley(ctx, expr, derivadaExpr, segundaDerivadaExpr, x0, criterio)
+}
+
+// Muller interpola f por parábolas e, como as iterações são complexas, pode
+// convergir para raízes complexas mesmo partindo de pontos reais. A parte
+// imaginária da raiz fica em Resultado.Imaginario.
+func Muller(funcao Expressao, criterio CriterioParada) (Resultado, error) {
+	if err := criterio.validar(); err != nil {
+		return Resultado{}, err
+	}
+	expr, err := newExpressaoComplexa(funcao)
+	if err != nil {
+		return Resultado{}, err
 	}
 
 	const timeOut = time.Second * 5
 	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
 	defer cancel()
 
-	return newtonRalphson(ctx, expr, derivadaExpr, k)
+	a := funcao.A
+	b := funcao.B
+	if a == b {
+		a, b = a-1, b+1
+	}
+	return muller(ctx, expr, complex(a, 0), complex((a+b)/2, 0), complex(b, 0), criterio)
 }
 
-func Secante(funcao Expressao, k int) (float64, error) {
+func Ridders(funcao Expressao, criterio CriterioParada) (Resultado, error) {
+	if err := criterio.validar(); err != nil {
+		return Resultado{}, err
+	}
 	expr, err := NewExpressaoAvaliavel(funcao)
 	if err != nil {
-		return 0.0, err
+		return Resultado{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	return ridders(ctx, expr, criterio)
+}
+
+// NewtonRalphsonMultiplo executa Newton-Raphson a partir de n chutes igualmente
+// espaçados em [A, B] e devolve as raízes distintas para as quais convergiu.
+func NewtonRalphsonMultiplo(funcao, derivada Expressao, n int, criterio CriterioParada) ([]float64, error) {
+	if err := criterio.validar(); err != nil {
+		return nil, err
+	}
+	expr, err := NewExpressaoAvaliavel(funcao)
+	if err != nil {
+		return nil, err
+	}
+
+	derivadaExpr, err := NewExpressaoAvaliavel(mesmoParametro(derivada, funcao))
+	if err != nil {
+		return nil, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	// cada chute só usa como intervalo de salvaguarda a sua célula da grade
+	return multiplosChutes(ctx, funcao, n, criterio, func(x0, x1 float64) (Resultado, error) {
+		return newtonRalphson(ctx, expr.comIntervalo(x0, x1).novaContagem(), derivadaExpr.novaContagem(), x0, criterio)
+	})
+}
+
+// SecanteMultipla executa a secante a partir de cada par de pontos vizinhos de
+// uma grade de n + 1 pontos em [A, B] e devolve as raízes distintas encontradas.
+func SecanteMultipla(funcao Expressao, n int, criterio CriterioParada) ([]float64, error) {
+	if err := criterio.validar(); err != nil {
+		return nil, err
+	}
+	expr, err := NewExpressaoAvaliavel(funcao)
+	if err != nil {
+		return nil, err
 	}
 
 	const timeOut = time.Second * 5
 	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
 	defer cancel()
 
-	//precisaoEsperada := math.Pow10(-k)
+	return multiplosChutes(ctx, funcao, n, criterio, func(x0, x1 float64) (Resultado, error) {
+		return secante(ctx, expr.novaContagem(), x0, x1, criterio)
+	})
+}
+
+// ChuteInicial é o ponto de partida padrão dos métodos abertos de um ponto: o
+// ponto médio de [A, B].
+func ChuteInicial(funcao Expressao) float64 {
+	return (funcao.A + funcao.B) / 2
+}
+
+// ChutesIniciais são os pontos de partida padrão da secante: os extremos A e B,
+// ou A e A + 1 quando o intervalo é degenerado.
+func ChutesIniciais(funcao Expressao) (float64, float64) {
+	if funcao.A == funcao.B {
+		return funcao.A, funcao.A + 1
+	}
+	return funcao.A, funcao.B
+}
+
+// multiplosChutes aplica metodo a partir de cada ponto de uma grade de n
+// subintervalos em [A, B], recebendo também o ponto seguinte da grade.
+func multiplosChutes(ctx context.Context, funcao Expressao, n int, criterio CriterioParada, metodo func(x0, x1 float64) (Resultado, error)) ([]float64, error) {
+	if n < 1 {
+		return nil, errors.New("o número de chutes deve ser positivo")
+	}
+	a, b := ChutesIniciais(funcao)
+	step := (b - a) / float64(n)
+
+	var raizes []float64
+	for i := 0; i < n; i++ {
+		x0 := a + float64(i)*step
+		resultado, err := metodo(x0, x0+step)
+		if err != nil {
+			if ctx.Err() != nil {
+				return raizesDistintas(raizes, criterio.toleranciaPasso()), err
+			}
+			// chutes que divergem são descartados
+			continue
+		}
+		if resultado.Convergiu {
+			raizes = append(raizes, resultado.Valor)
+		}
+	}
+	return raizesDistintas(raizes, criterio.toleranciaPasso()), nil
+}
 
-	return secante(ctx, expr, k)
+// mesmoParametro faz a derivada usar a variável, as constantes e as
+// definições de f quando não declara as suas.
+func mesmoParametro(derivada, funcao Expressao) Expressao {
+	if derivada.Parametro == "" {
+		derivada.Parametro = funcao.Parametro
+	}
+	if derivada.Constantes == nil {
+		derivada.Constantes = funcao.Constantes
+	}
+	if derivada.Definicoes == nil {
+		derivada.Definicoes = funcao.Definicoes
+	}
+	return derivada
 }
 
-func bisseccao(ctx context.Context, funcao ExpressaoAvaliavel, n int) (float64, error) {
+func bisseccao(ctx context.Context, funcao ExpressaoAvaliavel, criterio CriterioParada) (Resultado, error) {
+	var resultado Resultado
 	params := make(map[string]interface{}, 1)
 
 	params[funcao.expr.Parametro] = funcao.expr.A
 	fa, err := funcao.Avaliar(params)
 	if err != nil {
-		return 0.0, err
+		return resultado, err
 	}
 	if fa == 0 {
-		return funcao.expr.A, nil
+		resultado.Valor = funcao.expr.A
+		resultado.Convergiu = true
+		return resultado, nil
 	}
 
 	params[funcao.expr.Parametro] = funcao.expr.B
 	fb, err := funcao.Avaliar(params)
 	if err != nil {
-		return 0.0, err
+		return resultado, err
 	}
 	if fb == 0 {
-		return funcao.expr.B, nil
+		resultado.Valor = funcao.expr.B
+		resultado.Convergiu = true
+		return resultado, nil
 	}
 
 	if fa*fb > 0 {
-		return 0.0, errors.New("os sinais de f(a) e f(b) não são opostos")
+		return resultado, errors.New("os sinais de f(a) e f(b) não são opostos")
 	}
 
 	a := funcao.expr.A
 	b := funcao.expr.B
-	var p, fp float64
-
-	for i := 0; i < n; i++ {
+	p := a
+	for i := 1; ; i++ {
+		anterior := p
 		p = (a + b) / 2.0
 		params[funcao.expr.Parametro] = p
-		fp, err = funcao.Avaliar(params)
+		fp, err := funcao.Avaliar(params)
 		if err != nil {
-			return 0.0, err
+			return resultado, err
 		}
 		if fa*fp < 0 { //fa e fp tem sinais opostos
 			b = p
 		} else { // fp e fb tem sinais opostos
 			a = p
+			fa = fp
+		}
+
+		estado := estadoIteracao{i, funcao.Avaliacoes(), p, anterior, fp}
+		if criterio.parar(&resultado, estado) {
+			return resultado, nil
 		}
 		select {
 		case <-ctx.Done():
-			return p, ctx.Err()
+			return resultado, ctx.Err()
 		default:
 			continue
 		}
 	}
-	return p, nil
 }
 
-func posicaoFalsa(ctx context.Context, funcao ExpressaoAvaliavel, k int) (float64, error) {
+func posicaoFalsa(ctx context.Context, funcao ExpressaoAvaliavel, variante VariantePosicaoFalsa, criterio CriterioParada) (Resultado, error) {
+	var resultado Resultado
+	fator, err := fatorPosicaoFalsa(variante)
+	if err != nil {
+		return resultado, err
+	}
+
 	params := make(map[string]interface{}, 1)
-	precisaoEsperada := math.Pow10(-k)
 	params[funcao.expr.Parametro] = funcao.expr.A
 	fa, err := funcao.Avaliar(params)
 	if err != nil {
-		return 0.0, err
+		return resultado, err
 	}
 	if fa == 0 {
-		return funcao.expr.A, nil
+		resultado.Valor = funcao.expr.A
+		resultado.Convergiu = true
+		return resultado, nil
 	}
 
 	params[funcao.expr.Parametro] = funcao.expr.B
 	fb, err := funcao.Avaliar(params)
 	if err != nil {
-		return 0.0, err
+		return resultado, err
 	}
 	if fb == 0 {
-		return funcao.expr.B, nil
+		resultado.Valor = funcao.expr.B
+		resultado.Convergiu = true
+		return resultado, nil
 	}
 
 	if fa*fb > 0 {
-		return 0.0, errors.New("os sinais de f(a) e f(b) não são opostos")
+		return resultado, errors.New("os sinais de f(a) e f(b) não são opostos")
 	}
 
 	a := funcao.expr.A
 	b := funcao.expr.B
-	for {
-		xk := a*fb - b*fa/(fb-fa)
+	xk := a
+	// lado indica qual extremo foi mantido na iteração anterior: -1 para a, 1 para b
+	lado := 0
+	for i := 1; ; i++ {
+		anterior := xk
+		xk = (a*fb - b*fa) / (fb - fa)
 		params[funcao.expr.Parametro] = xk
 		fxk, err := funcao.Avaliar(params)
 		if err != nil {
-			return 0.0, err
-		}
-		if math.Abs(fxk) < precisaoEsperada {
-			return xk, nil
+			return resultado, err
 		}
 
+		// o extremo mantido duas vezes seguidas tem f reduzido pelo fator da variante
 		if fa*fxk > 0 {
-			a = xk
+			if lado == 1 {
+				fb *= fator(fa, fxk)
+			}
+			a, fa = xk, fxk
+			lado = 1
 		} else {
-			b = xk
+			if lado == -1 {
+				fa *= fator(fb, fxk)
+			}
+			b, fb = xk, fxk
+			lado = -1
 		}
 
-		params[funcao.expr.Parametro] = a
-		fa, err = funcao.Avaliar(params)
+		estado := estadoIteracao{i, funcao.Avaliacoes(), xk, anterior, fxk}
+		if criterio.parar(&resultado, estado) {
+			return resultado, nil
+		}
+		select {
+		case <-ctx.Done():
+			return resultado, ctx.Err()
+		default:
+			continue
+		}
+	}
+}
+
+// fatorPosicaoFalsa devolve o fator aplicado ao extremo estagnado, calculado a
+// partir do valor de f no extremo substituído e do novo ponto.
+func fatorPosicaoFalsa(variante VariantePosicaoFalsa) (func(fSubstituido, fNovo float64) float64, error) {
+	switch variante {
+	case PosicaoFalsaSimples:
+		return func(float64, float64) float64 { return 1 }, nil
+	case Illinois:
+		return func(float64, float64) float64 { return 0.5 }, nil
+	case Pegasus:
+		return func(fSubstituido, fNovo float64) float64 {
+			return fSubstituido / (fSubstituido + fNovo)
+		}, nil
+	case AndersonBjorck:
+		return func(fSubstituido, fNovo float64) float64 {
+			if m := 1 - fNovo/fSubstituido; m > 0 {
+				return m
+			}
+			return 0.5
+		}, nil
+	default:
+		return nil, errors.Errorf("variante de posição falsa desconhecida: %s", variante)
+	}
+}
+
+// newtonRalphson protege o método de Newton com salvaguardas: quando [A, B]
+// isola uma raiz, passos que saem do intervalo ou derivadas nulas recorrem à
+// bissecção, e passos que não reduzem |f| são amortecidos por busca linear.
+func newtonRalphson(ctx context.Context, funcao, derivada ExpressaoAvaliavel, x0 float64, criterio CriterioParada) (Resultado, error) {
+	var resultado Resultado
+	avaliacoes := func() int { return funcao.Avaliacoes() + derivada.Avaliacoes() }
+
+	a, b := funcao.expr.A, funcao.expr.B
+	comIntervalo := false
+	var fa float64
+	if a < b {
+		var fb float64
+		var err error
+		if fa, err = funcao.avaliarEm(a); err != nil {
+			return resultado, err
+		}
+		if fb, err = funcao.avaliarEm(b); err != nil {
+			return resultado, err
+		}
+		comIntervalo = fa*fb < 0
+	}
+
+	x := x0
+	if comIntervalo && !(a < x && x < b) {
+		x = (a + b) / 2
+		resultado.registrarSalvaguarda(SalvaguardaChuteForaDoIntervalo)
+	}
+	fx, err := funcao.avaliarEm(x)
+	if err != nil {
+		return resultado, err
+	}
+	if fx == 0 {
+		resultado.Valor = x
+		resultado.Convergiu = true
+		return resultado, nil
+	}
+
+	// esgotado encerra a iteração i em x se o limite de avaliações acabou
+	// antes de avaliar f no novo ponto, para que a busca linear e a bissecção
+	// não passem do limite
+	esgotado := func(i int) bool {
+		motivo := criterio.esgotado(estadoIteracao{i - 1, avaliacoes(), x, x, fx})
+		if motivo == "" {
+			return false
+		}
+		resultado.Valor, resultado.Iteracoes, resultado.Avaliacoes = x, i-1, avaliacoes()
+		resultado.Avisos = append(resultado.Avisos, motivo)
+		return true
+	}
+
+	// passos de Newton puros consecutivos, usados para estimar a multiplicidade
+	var passos []float64
+	for i := 1; ; i++ {
+		if comIntervalo {
+			if fa*fx > 0 {
+				a, fa = x, fx
+			} else {
+				b = x
+			}
+		}
+
+		dx, err := derivada.avaliarEm(x)
 		if err != nil {
-			return 0.0, err
+			return resultado, err
+		}
+
+		passo := fx / dx
+		p := x - passo
+		bisseccao := false
+		switch {
+		case dx == 0 || math.IsNaN(passo) || math.IsInf(passo, 0) || math.Abs(passo) > 1e8*(1+math.Abs(x)):
+			resultado.registrarSalvaguarda(SalvaguardaDerivadaNula)
+			if !comIntervalo {
+				resultado.Valor = x
+				return resultado, errors.Errorf("derivada nula ou muito pequena em x = %g e não há intervalo [a, b] com troca de sinal para recorrer à bissecção", x)
+			}
+			bisseccao = true
+		case comIntervalo && !(a < p && p < b):
+			bisseccao = true
+		}
+
+		var fp float64
+		lambda := 1.0
+		if bisseccao {
+			resultado.registrarSalvaguarda(SalvaguardaBisseccao)
+			p = (a + b) / 2
+			if esgotado(i) {
+				return resultado, nil
+			}
+			if fp, err = funcao.avaliarEm(p); err != nil {
+				return resultado, err
+			}
+		} else {
+			// busca linear: reduz o passo à metade até |f| diminuir o suficiente
+			// um ponto fora do domínio de f conta como um passo longo demais
+			if esgotado(i) {
+				return resultado, nil
+			}
+			fp, err = funcao.avaliarEm(p)
+			for lambda > 1.0/1024 {
+				if foraDoDominio(err) {
+					resultado.registrarSalvaguarda(SalvaguardaDominio)
+				} else if err != nil || math.Abs(fp) <= (1-1e-4*lambda)*math.Abs(fx) {
+					break
+				} else {
+					resultado.registrarSalvaguarda(SalvaguardaAmortecimento)
+				}
+				lambda /= 2
+				p = x - lambda*passo
+				if esgotado(i) {
+					return resultado, nil
+				}
+				fp, err = funcao.avaliarEm(p)
+			}
+			if err != nil {
+				return resultado, err
+			}
+			if math.IsNaN(fp) || math.IsInf(fp, 0) {
+				return resultado, errors.New("o método de Newton-Raphson divergiu")
+			}
+		}
+
+		if bisseccao || lambda != 1 {
+			passos = passos[:0]
+		} else {
+			passos = append(passos, passo)
+		}
+
+		estado := estadoIteracao{i, avaliacoes(), p, x, fp}
+		if criterio.parar(&resultado, estado) {
+			if resultado.Convergiu {
+				resultado.registrarMultiplicidade(multiplicidadePelaTaxa(passos))
+				if resultado.Multiplicidade > 1 {
+					resultado.Avisos = append(resultado.Avisos, fmt.Sprintf(
+						"a raiz parece ter multiplicidade %d e a convergência foi linear; NewtonMultiplicidade ou NewtonModificado convergem quadraticamente", resultado.Multiplicidade))
+				}
+			}
+			return resultado, nil
 		}
-		params[funcao.expr.Parametro] = b
-		fb, err = funcao.Avaliar(params)
+		x, fx = p, fp
+
+		select {
+		case <-ctx.Done():
+			return resultado, ctx.Err()
+		default:
+			continue
+		}
+	}
+}
+
+func secante(ctx context.Context, funcao ExpressaoAvaliavel, x0, x1 float64, criterio CriterioParada) (Resultado, error) {
+	var resultado Resultado
+	xa := x0
+	xb := x1
+
+	fxa, err := funcao.avaliarEm(xa)
+	if err != nil {
+		return resultado, err
+	}
+	fxb, err := funcao.avaliarEm(xb)
+	if err != nil {
+		return resultado, err
+	}
+
+	for i := 1; ; i++ {
+		if fxb == fxa {
+			resultado.Valor = xb
+			return resultado, errors.New("a secante ficou horizontal: f(x0) = f(x1)")
+		}
+		xr := ((xa * fxb) - (xb * fxa)) / (fxb - fxa)
+		xr, fxr, err := passoNoDominio(funcao, xb, xr, &resultado)
 		if err != nil {
-			return 0.0, err
+			return resultado, err
+		}
+		xa, fxa = xb, fxb
+		xb, fxb = xr, fxr
+
+		estado := estadoIteracao{i, funcao.Avaliacoes(), xb, xa, fxb}
+		if criterio.parar(&resultado, estado) {
+			return resultado, nil
+		}
+		select {
+		case <-ctx.Done():
+			return resultado, ctx.Err()
+		default:
+			continue
 		}
 	}
 }
 
-func newtonRalphson(ctx context.Context, funcao, derivada ExpressaoAvaliavel, k int) (float64, error) {
-	params := make(map[string]interface{}, 1)
-	//precisaoEsperada := math.Pow10(-k)
-	var xn float64 = 1
-	lastxn := xn
-	for {
-		params[funcao.expr.Parametro] = xn
-		fx, err := funcao.Avaliar(params)
+func halley(ctx context.Context, funcao, derivada, segundaDerivada ExpressaoAvaliavel, x0 float64, criterio CriterioParada) (Resultado, error) {
+	var resultado Resultado
+	x := x0
+	fx, err := funcao.avaliarEm(x)
+	if err != nil {
+		return resultado, err
+	}
+	for i := 1; ; i++ {
+		if fx == 0 {
+			resultado.Valor = x
+			resultado.Convergiu = true
+			return resultado, nil
+		}
+		dx, err := derivada.avaliarEm(x)
 		if err != nil {
-			return 0.0, err
+			return resultado, err
 		}
-		dx, err := derivada.Avaliar(params)
+		d2x, err := segundaDerivada.avaliarEm(x)
 		if err != nil {
-			return 0.0, err
+			return resultado, err
 		}
-		xn = xn - fx/dx
-		if xn == lastxn {
-			return xn, nil
+
+		denominador := 2*dx*dx - fx*d2x
+		if denominador == 0 {
+			resultado.Valor = x
+			return resultado, errors.New("o denominador do método de Halley se anulou")
+		}
+		anterior := x
+		x -= 2 * fx * dx / denominador
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return resultado, errors.New("o método de Halley divergiu")
+		}
+		if x, fx, err = passoNoDominio(funcao, anterior, x, &resultado); err != nil {
+			return resultado, err
+		}
+
+		avaliacoes := funcao.Avaliacoes() + derivada.Avaliacoes() + segundaDerivada.Avaliacoes()
+		if criterio.parar(&resultado, estadoIteracao{i, avaliacoes, x, anterior, fx}) {
+			return resultado, nil
+		}
+		select {
+		case <-ctx.Done():
+			return resultado, ctx.Err()
+		default:
+			continue
 		}
-		lastxn = xn
 	}
 }
 
-func secante(ctx context.Context, funcao ExpressaoAvaliavel, k int) (float64, error) {
-	params := make(map[string]interface{}, 1)
-	precisaoEsperada := math.Pow10(-k)
-	var xa float64 = 0
-	var xb float64 = 1
-	for {
-		params[funcao.expr.Parametro] = xa
-		fxa, err := funcao.Avaliar(params)
+func muller(ctx context.Context, funcao expressaoComplexa, x0, x1, x2 complex128, criterio CriterioParada) (Resultado, error) {
+	var resultado Resultado
+	f0, err := funcao.avaliar(x0)
+	if err != nil {
+		return resultado, err
+	}
+	f1, err := funcao.avaliar(x1)
+	if err != nil {
+		return resultado, err
+	}
+	f2, err := funcao.avaliar(x2)
+	if err != nil {
+		return resultado, err
+	}
+
+	for i := 1; ; i++ {
+		if f2 == 0 {
+			resultado.Valor, resultado.Imaginario = real(x2), imag(x2)
+			resultado.Convergiu = true
+			return resultado, nil
+		}
+		h1 := x1 - x0
+		h2 := x2 - x1
+		d1 := (f1 - f0) / h1
+		d2 := (f2 - f1) / h2
+		a := (d2 - d1) / (h2 + h1)
+		b := a*h2 + d2
+		discriminante := cmplx.Sqrt(b*b - 4*a*f2)
+
+		// escolhe o sinal que maximiza o denominador
+		denominador := b + discriminante
+		if cmplx.Abs(b-discriminante) > cmplx.Abs(denominador) {
+			denominador = b - discriminante
+		}
+		if denominador == 0 {
+			return resultado, errors.New("a parábola interpoladora do método de Muller degenerou")
+		}
+
+		x0, x1, x2 = x1, x2, x2-2*f2/denominador
+		f0, f1 = f1, f2
+		f2, err = funcao.avaliar(x2)
 		if err != nil {
-			return 0.0, err
+			return resultado, err
+		}
+		if cmplx.IsNaN(x2) || cmplx.IsInf(x2) {
+			return resultado, errors.New("o método de Muller divergiu")
 		}
-		params[funcao.expr.Parametro] = xb
-		fxb, err := funcao.Avaliar(params)
+
+		if criterio.pararComplexo(&resultado, i, funcao.avaliacoes, x2, x1, cmplx.Abs(f2)) {
+			return resultado, nil
+		}
+		select {
+		case <-ctx.Done():
+			return resultado, ctx.Err()
+		default:
+			continue
+		}
+	}
+}
+
+func ridders(ctx context.Context, funcao ExpressaoAvaliavel, criterio CriterioParada) (Resultado, error) {
+	var resultado Resultado
+	a := funcao.expr.A
+	b := funcao.expr.B
+	fa, err := funcao.avaliarEm(a)
+	if err != nil {
+		return resultado, err
+	}
+	if fa == 0 {
+		resultado.Valor = a
+		resultado.Convergiu = true
+		return resultado, nil
+	}
+	fb, err := funcao.avaliarEm(b)
+	if err != nil {
+		return resultado, err
+	}
+	if fb == 0 {
+		resultado.Valor = b
+		resultado.Convergiu = true
+		return resultado, nil
+	}
+	if fa*fb > 0 {
+		return resultado, errors.New("os sinais de f(a) e f(b) não são opostos")
+	}
+
+	x := a
+	for i := 1; ; i++ {
+		m := (a + b) / 2
+		fm, err := funcao.avaliarEm(m)
 		if err != nil {
-			return 0.0, err
+			return resultado, err
+		}
+		s := math.Sqrt(fm*fm - fa*fb)
+		if s == 0 {
+			resultado.Valor = m
+			resultado.Convergiu = true
+			return resultado, nil
+		}
+
+		// ajuste exponencial: x = m + (m - a) sinal(fa - fb) f(m) / s
+		sinal := 1.0
+		if fa < fb {
+			sinal = -1.0
+		}
+		anterior := x
+		x = m + (m-a)*sinal*fm/s
+		fx, err := funcao.avaliarEm(x)
+		if err != nil {
+			return resultado, err
+		}
+
+		switch {
+		case fm*fx < 0:
+			a, fa, b, fb = m, fm, x, fx
+		case fa*fx < 0:
+			b, fb = x, fx
+		default:
+			a, fa = x, fx
+		}
+
+		estado := estadoIteracao{i, funcao.Avaliacoes(), x, anterior, fx}
+		if criterio.parar(&resultado, estado) {
+			return resultado, nil
 		}
+		select {
+		case <-ctx.Done():
+			return resultado, ctx.Err()
+		default:
+			continue
+		}
+	}
+}
+
+// Resultado é o valor encontrado por um método iterativo junto com o número
+// de iterações e de avaliações realizadas e os avisos gerados durante a execução.
+type Resultado struct {
+	Valor float64 `json:"result"`
+	// Imaginario é a parte imaginária da raiz, preenchida pelo método de Muller
+	Imaginario float64 `json:"imaginario,omitempty"`
+	// Convergiu é falso quando o método parou por um limite do critério
+	Convergiu  bool     `json:"convergiu"`
+	Iteracoes  int      `json:"iteracoes"`
+	Avaliacoes int      `json:"avaliacoes"`
+	Avisos     []string `json:"avisos,omitempty"`
+	// Salvaguardas lista as proteções acionadas durante a execução
+	Salvaguardas []string `json:"salvaguardas,omitempty"`
+	// Multiplicidade é a multiplicidade estimada da raiz, zero quando desconhecida
+	Multiplicidade int `json:"multiplicidade,omitempty"`
+	// Historico guarda a aproximação obtida em cada iteração; no método de
+	// Muller, a parte real, com a imaginária em HistoricoImaginario
+	Historico           []float64 `json:"historico,omitempty"`
+	HistoricoImaginario []float64 `json:"historicoImaginario,omitempty"`
+	// Ordem é a ordem de convergência estimada pelos últimos passos
+	Ordem *OrdemConvergencia `json:"ordem,omitempty"`
+
+	// passos guarda |x_k - x_{k-1}|, que no método de Muller é medido no plano complexo
+	passos []float64
+}
+
+// Salvaguardas que os métodos abertos podem registrar em
+// Resultado.Salvaguardas. Só SalvaguardaDominio é usada também por Secante e
+// Halley; as demais são de NewtonRalphson.
+const (
+	SalvaguardaDerivadaNula         = "derivada nula"
+	SalvaguardaAmortecimento        = "amortecimento"
+	SalvaguardaBisseccao            = "bissecção"
+	SalvaguardaChuteForaDoIntervalo = "chute fora do intervalo"
+	SalvaguardaDominio              = "passo reduzido por sair do domínio"
+)
+
+func (r *Resultado) registrarSalvaguarda(salvaguarda string) {
+	for _, s := range r.Salvaguardas {
+		if s == salvaguarda {
+			return
+		}
+	}
+	r.Salvaguardas = append(r.Salvaguardas, salvaguarda)
+}
+
+// Aceleracao seleciona a aceleração de convergência usada em PontoFixo.
+type Aceleracao string
+
+const (
+	SemAceleracao Aceleracao = ""
+	Aitken        Aceleracao = "aitken"
+	Steffensen    Aceleracao = "steffensen"
+)
+
+// PontoFixo resolve x = g(x) partindo de x0, opcionalmente acelerando a
+// convergência pelo Δ² de Aitken, aplicado à sequência x_{k+1} = g(x_k), ou
+// pelo método de Steffensen, que reinicia a sequência em cada estimativa.
+func PontoFixo(g Expressao, x0 float64, aceleracao Aceleracao, criterio CriterioParada) (Resultado, error) {
+	if err := criterio.validar(); err != nil {
+		return Resultado{}, err
+	}
+	expr, err := NewExpressaoAvaliavel(g)
+	if err != nil {
+		return Resultado{}, err
+	}
+
+	const timeOut = time.Second * 5
+	ctx, cancel := context.WithTimeout(context.Background(), timeOut)
+	defer cancel()
+
+	return pontoFixo(ctx, expr, x0, aceleracao, criterio)
+}
 
-		fxr := ((xa * fxb) - (xb * fxa)) / (fxb - fxa)
+func pontoFixo(ctx context.Context, g ExpressaoAvaliavel, x0 float64, aceleracao Aceleracao, criterio CriterioParada) (Resultado, error) {
+	var resultado Resultado
+
+	switch aceleracao {
+	case SemAceleracao, Aitken, Steffensen:
+	default:
+		return resultado, errors.Errorf("aceleração desconhecida: %s", aceleracao)
+	}
+
+	// a iteração só converge garantidamente se |g'(x)| < 1 perto do ponto fixo
+	dg, err := derivadaNumerica(g, x0)
+	if err != nil {
+		return resultado, err
+	}
+	if math.Abs(dg) >= 1 {
+		resultado.Avisos = append(resultado.Avisos,
+			fmt.Sprintf("|g'(x0)| ≈ %.4g ≥ 1, a iteração pode divergir", math.Abs(dg)))
+	}
 
-		params[funcao.expr.Parametro] = fxr
-		r, err := funcao.Avaliar(params)
+	// x é o iterado atual e gx = g(x). Sem aceleração, cada iteração avança
+	// um iterado; Aitken aplica o Δ² aos três últimos iterados da sequência
+	// simples, e Steffensen recomeça dela a partir de cada estimativa. O
+	// resíduo é sempre |g(x) - x| na estimativa devolvida, o que custa às
+	// acelerações uma avaliação a mais por iteração.
+	x := x0
+	gx, err := g.avaliarEm(x)
+	if err != nil {
+		return resultado, err
+	}
+	anterior := x0
+	for i := 1; ; i++ {
+		x1 := gx
+		g1, err := g.avaliarEm(x1)
 		if err != nil {
-			return 0.0, err
+			return resultado, err
+		}
+		estimativa, residuo := x1, g1-x1
+		if aceleracao != SemAceleracao {
+			estimativa = aitken(x, x1, g1)
 		}
-		if math.Abs(r) < precisaoEsperada {
-			return fxr, nil
+		if math.IsNaN(estimativa) || math.IsInf(estimativa, 0) {
+			return resultado, errors.New("a iteração de ponto fixo divergiu")
 		}
 
-		xa = fxr
+		switch aceleracao {
+		case SemAceleracao:
+			x, gx = x1, g1
+		default:
+			gEstimativa, err := g.avaliarEm(estimativa)
+			if err != nil {
+				return resultado, err
+			}
+			residuo = gEstimativa - estimativa
+			if aceleracao == Steffensen {
+				x, gx = estimativa, gEstimativa
+			} else {
+				x, gx = x1, g1
+			}
+		}
+
+		if criterio.parar(&resultado, estadoIteracao{i, g.Avaliacoes(), estimativa, anterior, residuo}) {
+			return resultado, nil
+		}
+		anterior = estimativa
+
+		select {
+		case <-ctx.Done():
+			return resultado, ctx.Err()
+		default:
+			continue
+		}
+	}
+}
+
+// aitken aplica o Δ² de Aitken a três iterados consecutivos.
+func aitken(x0, x1, x2 float64) float64 {
+	denominador := x2 - 2*x1 + x0
+	if denominador == 0 {
+		return x2
+	}
+	return x0 - (x1-x0)*(x1-x0)/denominador
+}
+
+// derivadaNumerica estima f'(x) por diferenças centrais.
+func derivadaNumerica(funcao ExpressaoAvaliavel, x float64) (float64, error) {
+	h := 1e-5 * math.Max(1, math.Abs(x))
+	fmais, err := funcao.avaliarEm(x + h)
+	if err != nil {
+		return 0.0, err
+	}
+	fmenos, err := funcao.avaliarEm(x - h)
+	if err != nil {
+		return 0.0, err
 	}
+	return (fmais - fmenos) / (2 * h), nil
 }
@@ -0,0 +1,498 @@
+package metodos
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestBuscarRaizes(t *testing.T) {
+	// duas raízes simples: f(a) e f(b) têm o mesmo sinal
+	funcao := Expressao{Corpo: "x**2 - 4", Parametro: "x", A: -3, B: 3}
+	for _, metodo := range []string{"bissecao", "posicaofalsa"} {
+		r, err := BuscarRaizes(funcao, metodo, 50, NewCriterioParada(8))
+		if err != nil {
+			t.Fatalf("%s: %v", metodo, err)
+		}
+		if raizes := r.Raizes; len(raizes) != 2 || math.Abs(raizes[0]+2) > 1e-6 || math.Abs(raizes[1]-2) > 1e-6 {
+			t.Errorf("%s: raízes esperadas [-2 2], obtidas %v", metodo, raizes)
+		}
+	}
+
+	// a amostragem não consome o limite de avaliações dos refinamentos
+	criterio := NewCriterioParada(8)
+	criterio.MaxAvaliacoes = 40
+	cubica := Expressao{Corpo: "x**3 - x", Parametro: "x", A: -2, B: 2.1}
+	r, err := BuscarRaizes(cubica, "bissecao", 50, criterio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !raizesProximas(r.Raizes, []float64{-1, 0, 1}, 1e-8) || len(r.Avisos) != 0 {
+		t.Errorf("raízes esperadas [-1 0 1], obtido %+v", r)
+	}
+
+	// as raízes que esgotam o limite vêm com um aviso
+	criterio.MaxAvaliacoes = 5
+	r, err = BuscarRaizes(cubica, "bissecao", 50, criterio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !raizesProximas(r.Raizes, []float64{-1, 0, 1}, 1e-2) || len(r.Avisos) != 3 {
+		t.Errorf("esperadas três raízes aproximadas com avisos, obtido %+v", r)
+	}
+}
+
+func TestBuscarRaizesDupla(t *testing.T) {
+	funcao := Expressao{Corpo: "(x - 1) * (x - 2.05)**2", Parametro: "x", A: 0, B: 3}
+	r, err := BuscarRaizes(funcao, "bissecao", 40, NewCriterioParada(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raizes := r.Raizes; len(raizes) != 2 || math.Abs(raizes[0]-1) > 1e-5 || math.Abs(raizes[1]-2.05) > 1e-2 {
+		t.Errorf("raízes esperadas [1 2.05], obtidas %v", raizes)
+	}
+
+	// raízes duplas na primeira e na última célula da amostragem
+	funcao = Expressao{Corpo: "(x - 0.02)**2 * (x - 2.97)**2", Parametro: "x", A: 0, B: 3}
+	isolamento, err := IsolarRaizes(funcao, 30, NewCriterioParada(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if intervalos := isolamento.Intervalos; len(intervalos) != 2 || intervalos[0] != (Intervalo{0, 0.1, false}) || intervalos[1].B != 3 {
+		t.Errorf("esperados os intervalos das duas células dos extremos, obtidos %v", isolamento)
+	}
+
+	// uma precisão abaixo do espaçamento dos float64 não pode travar a busca
+	expr, err := NewExpressaoAvaliavel(Expressao{Corpo: "(x - 1000000.5)**2", Parametro: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if x, _, err := minimoAbsoluto(ctx, expr, 1e6, 1e6+1, 1e-15); err != nil || math.Abs(x-1000000.5) > 1e-3 {
+		t.Errorf("mínimo esperado em 1000000.5, obtido %v (%v)", x, err)
+	}
+}
+
+func TestPontoFixo(t *testing.T) {
+	// x = cos(x) tem ponto fixo em 0.7390851332151607
+	g := Expressao{Corpo: "cos(x)", Parametro: "x"}
+	var iteracoes []int
+	for _, aceleracao := range []Aceleracao{SemAceleracao, Aitken, Steffensen} {
+		r, err := PontoFixo(g, 1, aceleracao, NewCriterioParada(10))
+		if err != nil {
+			t.Fatalf("%q: %v", aceleracao, err)
+		}
+		if math.Abs(r.Valor-0.7390851332151607) > 1e-9 {
+			t.Errorf("%q: ponto fixo esperado 0.739085..., obtido %v", aceleracao, r.Valor)
+		}
+		if len(r.Avisos) != 0 {
+			t.Errorf("%q: avisos inesperados %v", aceleracao, r.Avisos)
+		}
+		iteracoes = append(iteracoes, r.Iteracoes)
+
+		// o resíduo é medido no valor devolvido
+		r, err = PontoFixo(g, 1, aceleracao, CriterioParada{Residuo: 1e-12})
+		if err != nil || math.Abs(math.Cos(r.Valor)-r.Valor) >= 1e-12 {
+			t.Errorf("%q: resíduo %v no valor devolvido %v (%v)", aceleracao, math.Cos(r.Valor)-r.Valor, r.Valor, err)
+		}
+	}
+	if iteracoes[1] >= iteracoes[0] || iteracoes[2] >= iteracoes[1] {
+		t.Errorf("Aitken e Steffensen deveriam precisar de menos iterações: %v", iteracoes)
+	}
+}
+
+func TestPontoFixoAvisaDivergencia(t *testing.T) {
+	g := Expressao{Corpo: "2*x + 1", Parametro: "x"}
+	r, err := PontoFixo(g, 1, SemAceleracao, NewCriterioParada(6))
+	if err == nil {
+		t.Error("esperado erro de divergência")
+	}
+	if len(r.Avisos) == 0 {
+		t.Error("esperado aviso de |g'(x0)| ≥ 1")
+	}
+}
+
+func TestHalley(t *testing.T) {
+	funcao := Expressao{Corpo: "x**3 - 2*x - 5", Parametro: "x", A: 2, B: 3}
+	derivada := Expressao{Corpo: "3*x**2 - 2"}
+	segundaDerivada := Expressao{Corpo: "6*x"}
+	r, err := Halley(funcao, derivada, segundaDerivada, ChuteInicial(funcao), NewCriterioParada(12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-2.0945514815423265) > 1e-12 {
+		t.Errorf("raiz esperada 2.0945514815423265, obtida %v", r.Valor)
+	}
+}
+
+func TestMullerRaizComplexa(t *testing.T) {
+	// x² + 1 não tem raízes reais
+	funcao := Expressao{Corpo: "x**2 + 1", Parametro: "x", A: 0, B: 2}
+	r, err := Muller(funcao, NewCriterioParada(12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor) > 1e-10 || math.Abs(math.Abs(r.Imaginario)-1) > 1e-10 {
+		t.Errorf("raiz esperada ±i, obtida %v%+vi", r.Valor, r.Imaginario)
+	}
+
+	// o histórico guarda os iterados complexos, não os seus módulos
+	n := len(r.Historico)
+	if n != r.Iteracoes || len(r.HistoricoImaginario) != n || r.Historico[n-1] != r.Valor || r.HistoricoImaginario[n-1] != r.Imaginario {
+		t.Errorf("histórico inconsistente com a raiz %v%+vi: %v, %v", r.Valor, r.Imaginario, r.Historico, r.HistoricoImaginario)
+	}
+}
+
+func TestRidders(t *testing.T) {
+	funcao := Expressao{Corpo: "cos(x) - x", Parametro: "x", A: 0, B: 1}
+	r, err := Ridders(funcao, NewCriterioParada(12))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-0.7390851332151607) > 1e-10 {
+		t.Errorf("raiz esperada 0.739085..., obtida %v", r.Valor)
+	}
+}
+
+func TestPosicaoFalsaVariantes(t *testing.T) {
+	// função convexa: na posição falsa simples o extremo b nunca se move
+	funcao := Expressao{Corpo: "x**3 - 2", Parametro: "x", A: 0, B: 3}
+	simples, err := PosicaoFalsa(funcao, PosicaoFalsaSimples, NewCriterioParada(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, variante := range []VariantePosicaoFalsa{Illinois, Pegasus, AndersonBjorck} {
+		r, err := PosicaoFalsa(funcao, variante, NewCriterioParada(10))
+		if err != nil {
+			t.Fatalf("%s: %v", variante, err)
+		}
+		if math.Abs(r.Valor-math.Cbrt(2)) > 1e-9 {
+			t.Errorf("%s: raiz esperada %v, obtida %v", variante, math.Cbrt(2), r.Valor)
+		}
+		if r.Iteracoes >= simples.Iteracoes {
+			t.Errorf("%s: %d iterações, a posição falsa simples usou %d", variante, r.Iteracoes, simples.Iteracoes)
+		}
+		t.Logf("%s: %d iterações (simples: %d)", variante, r.Iteracoes, simples.Iteracoes)
+	}
+}
+
+func TestCriterioParada(t *testing.T) {
+	funcao := Expressao{Corpo: "x**2 - 2", Parametro: "x", A: 0, B: 2}
+
+	r, err := Bisseccao(funcao, CriterioParada{MaxIteracoes: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Iteracoes != 10 || len(r.Avisos) != 1 {
+		t.Errorf("esperadas 10 iterações e um aviso de limite, obtido %+v", r)
+	}
+
+	r, err = Secante(funcao, 0, 1, CriterioParada{PassoRelativo: 1e-12, Residuo: 1e-3, Combinacao: E})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-math.Sqrt2) > 1e-11 {
+		t.Errorf("raiz esperada √2, obtida %v", r.Valor)
+	}
+
+	r, err = NewtonRalphson(funcao, Expressao{Corpo: "2*x"}, 1, CriterioParada{MaxAvaliacoes: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Convergiu || r.Avaliacoes != 6 {
+		t.Errorf("esperada parada após 6 avaliações, obtido %+v", r)
+	}
+
+	// nem a busca linear passa do limite
+	arcoTangente := Expressao{Corpo: "atan(x)", Parametro: "x"}
+	for limite := 2; limite <= 12; limite++ {
+		r, err = NewtonRalphson(arcoTangente, Expressao{Corpo: "1/(1 + x**2)"}, 3, CriterioParada{Residuo: 1e-12, MaxAvaliacoes: limite})
+		if err != nil || r.Avaliacoes > limite {
+			t.Errorf("limite de %d avaliações: obtido %+v (%v)", limite, r, err)
+		}
+	}
+
+	if _, err := Bisseccao(funcao, CriterioParada{}); err == nil {
+		t.Error("esperado erro para critério sem tolerância nem limite")
+	}
+}
+
+func TestChutesIniciais(t *testing.T) {
+	funcao := Expressao{Corpo: "x**2 - 4", Parametro: "x", A: -3, B: -1}
+	derivada := Expressao{Corpo: "2*x"}
+
+	r, err := NewtonRalphson(funcao, derivada, ChuteInicial(funcao), NewCriterioParada(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor+2) > 1e-9 {
+		t.Errorf("Newton partindo de [-3, -1]: raiz esperada -2, obtida %v", r.Valor)
+	}
+
+	x0, x1 := ChutesIniciais(funcao)
+	r, err = Secante(funcao, x0, x1, NewCriterioParada(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor+2) > 1e-9 {
+		t.Errorf("secante partindo de [-3, -1]: raiz esperada -2, obtida %v", r.Valor)
+	}
+}
+
+func TestMultiplosChutes(t *testing.T) {
+	funcao := Expressao{Corpo: "x**3 - x", Parametro: "x", A: -2, B: 2}
+	esperadas := []float64{-1, 0, 1}
+
+	raizes, err := NewtonRalphsonMultiplo(funcao, Expressao{Corpo: "3*x**2 - 1"}, 9, NewCriterioParada(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !raizesProximas(raizes, esperadas, 1e-8) {
+		t.Errorf("Newton: raízes esperadas %v, obtidas %v", esperadas, raizes)
+	}
+
+	// o limite de avaliações vale para cada chute, não para todos juntos
+	criterio := NewCriterioParada(10)
+	criterio.MaxAvaliacoes = 40
+	raizes, err = SecanteMultipla(funcao, 9, criterio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !raizesProximas(raizes, esperadas, 1e-8) {
+		t.Errorf("secante: raízes esperadas %v, obtidas %v", esperadas, raizes)
+	}
+}
+
+func raizesProximas(obtidas, esperadas []float64, tol float64) bool {
+	if len(obtidas) != len(esperadas) {
+		return false
+	}
+	for i := range obtidas {
+		if math.Abs(obtidas[i]-esperadas[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNewtonRalphsonSalvaguardas(t *testing.T) {
+	derivada := Expressao{Corpo: "2*x"}
+
+	// f'(0) = 0: sem intervalo não há como continuar
+	semIntervalo := Expressao{Corpo: "x**2 - 4", Parametro: "x"}
+	if _, err := NewtonRalphson(semIntervalo, derivada, 0, NewCriterioParada(10)); err == nil {
+		t.Error("esperado erro de derivada nula sem intervalo")
+	}
+
+	// com [A, B] isolando a raiz o método recorre à bissecção
+	comIntervalo := Expressao{Corpo: "x**2 - 4", Parametro: "x", A: -1, B: 3}
+	r, err := NewtonRalphson(comIntervalo, derivada, 0, NewCriterioParada(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor-2) > 1e-9 {
+		t.Errorf("raiz esperada 2, obtida %v", r.Valor)
+	}
+	if !contem(r.Salvaguardas, SalvaguardaDerivadaNula) || !contem(r.Salvaguardas, SalvaguardaBisseccao) {
+		t.Errorf("salvaguardas esperadas %q e %q, obtidas %v", SalvaguardaDerivadaNula, SalvaguardaBisseccao, r.Salvaguardas)
+	}
+
+	// partindo de 0, Newton puro cicla entre 0 e 1
+	ciclo := Expressao{Corpo: "x**3 - 2*x + 2", Parametro: "x"}
+	r, err = NewtonRalphson(ciclo, Expressao{Corpo: "3*x**2 - 2"}, 0, NewCriterioParada(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(r.Valor+1.7692923542386314) > 1e-9 {
+		t.Errorf("raiz esperada -1.769292..., obtida %v", r.Valor)
+	}
+	if !contem(r.Salvaguardas, SalvaguardaAmortecimento) {
+		t.Errorf("salvaguarda esperada %q, obtidas %v", SalvaguardaAmortecimento, r.Salvaguardas)
+	}
+}
+
+func TestErroDominio(t *testing.T) {
+	logaritmo := Expressao{Corpo: "logn(x)", Parametro: "x", A: -1, B: 2}
+
+	// os métodos de intervalo não têm como contornar o ponto indefinido
+	_, err := Bisseccao(logaritmo, NewCriterioParada(8))
+	if erro, ok := errors.Cause(err).(*ErroDominio); !ok || erro.Funcao != "logn" || erro.X != -1 {
+		t.Errorf("esperado *ErroDominio em logn(-1), obtido %v", err)
+	}
+
+	// os métodos abertos encurtam o passo que sai do domínio
+	semIntervalo := Expressao{Corpo: "logn(x)", Parametro: "x"}
+	r, err := NewtonRalphson(semIntervalo, Expressao{Corpo: "1/x"}, 3, NewCriterioParada(10))
+	if err != nil || math.Abs(r.Valor-1) > 1e-9 || !contem(r.Salvaguardas, SalvaguardaDominio) {
+		t.Errorf("Newton: esperada a raiz 1 com a salvaguarda %q, obtido %+v (%v)", SalvaguardaDominio, r, err)
+	}
+	r, err = Secante(semIntervalo, 3, 2.5, NewCriterioParada(10))
+	if err != nil || math.Abs(r.Valor-1) > 1e-9 || !contem(r.Salvaguardas, SalvaguardaDominio) {
+		t.Errorf("secante: esperada a raiz 1 com a salvaguarda %q, obtido %+v (%v)", SalvaguardaDominio, r, err)
+	}
+
+	// o isolamento ignora as amostras indefinidas, avisando quais foram
+	raizes, err := BuscarRaizes(logaritmo, "bissecao", 30, NewCriterioParada(8))
+	if err != nil || len(raizes.Raizes) != 1 || math.Abs(raizes.Raizes[0]-1) > 1e-6 {
+		t.Errorf("raízes esperadas [1], obtido %+v (%v)", raizes, err)
+	}
+	if len(raizes.Avisos) != 1 || !strings.HasPrefix(raizes.Avisos[0], "10 amostras em [-1, ") {
+		t.Errorf("esperado um aviso sobre as amostras em que x < 0, obtido %v", raizes.Avisos)
+	}
+
+	// um estouro não é erro de domínio, ao contrário de 0/0
+	casos := []struct {
+		corpo   string
+		x       float64
+		dominio bool
+	}{
+		{"x/x", 0, true},
+		{"x % 0", 1, true},
+		{"(x - 1)*exp(1000*x)", 1, false},
+		{"exp(1000*x) - exp(1000*x)", 1, false},
+	}
+	for _, c := range casos {
+		expr, err := NewExpressaoAvaliavel(Expressao{Corpo: c.corpo, Parametro: "x"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err := expr.avaliarEm(c.x)
+		if foraDoDominio(err) != c.dominio || !c.dominio && !math.IsNaN(v) {
+			t.Errorf("%s em %v: obtido %v (%v)", c.corpo, c.x, v, err)
+		}
+	}
+
+	// nem avisa de domínio na quadratura tanh-sinh, em que x**2 estoura
+	// antes de exp(-x) se anular
+	integral, err := TanhSinh(Expressao{Corpo: "x**2*exp(-x)", Parametro: "x", A: 0, B: math.Inf(1)}, NewCriterioParada(10))
+	if err != nil || math.Abs(integral.Valor-2) > 1e-9 || len(integral.Avisos) != 0 {
+		t.Errorf("esperado 2 sem avisos, obtido %+v (%v)", integral, err)
+	}
+}
+
+func contem(lista []string, s string) bool {
+	for _, v := range lista {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMultiplicidade(t *testing.T) {
+	// 2 é raiz dupla de (x-2)²(x+1)
+	funcao := Expressao{Corpo: "(x-2)**2 * (x+1)", Parametro: "x"}
+	derivada := Expressao{Corpo: "2*(x-2)*(x+1) + (x-2)**2"}
+	segundaDerivada := Expressao{Corpo: "6*x - 6"}
+	// numa raiz dupla, |f| < 10^-10 ainda deixa x a uns 10^-5 da raiz
+	criterio := NewCriterioParada(10)
+	criterio.Combinacao = E
+
+	newton, err := NewtonRalphson(funcao, derivada, 3, criterio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newton.Multiplicidade != 2 || len(newton.Avisos) == 0 {
+		t.Errorf("Newton deveria estimar multiplicidade 2 e avisar, obteve %d %v", newton.Multiplicidade, newton.Avisos)
+	}
+
+	m, err := EstimarMultiplicidade(funcao, derivada, newton.Valor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != 2 {
+		t.Errorf("multiplicidade esperada 2, obtida %d", m)
+	}
+
+	comM, err := NewtonMultiplicidade(funcao, derivada, m, 3, criterio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(comM.Valor-2) > 1e-8 || comM.Iteracoes >= newton.Iteracoes {
+		t.Errorf("Newton com m = 2 deveria convergir a 2 mais rápido: %v em %d iterações contra %d", comM.Valor, comM.Iteracoes, newton.Iteracoes)
+	}
+
+	modificado, err := NewtonModificado(funcao, derivada, segundaDerivada, 3, criterio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(modificado.Valor-2) > 1e-8 || modificado.Multiplicidade != 2 {
+		t.Errorf("Newton modificado deveria achar a raiz dupla 2, obteve %v com multiplicidade %d", modificado.Valor, modificado.Multiplicidade)
+	}
+
+	// o resíduo que encerra a iteração é o do valor devolvido
+	f := func(x float64) float64 { return (x - 2) * (x - 2) * (x + 1) }
+	comM, err = NewtonMultiplicidade(funcao, derivada, 2, 3, CriterioParada{Residuo: 1e-10})
+	if err != nil || math.Abs(f(comM.Valor)) >= 1e-10 {
+		t.Errorf("Newton com m = 2: |f(%v)| = %v (%v)", comM.Valor, math.Abs(f(comM.Valor)), err)
+	}
+	modificado, err = NewtonModificado(funcao, derivada, segundaDerivada, 3, CriterioParada{Residuo: 1e-10})
+	if err != nil || math.Abs(f(modificado.Valor)) >= 1e-10 {
+		t.Errorf("Newton modificado: |f(%v)| = %v (%v)", modificado.Valor, math.Abs(f(modificado.Valor)), err)
+	}
+
+	simples, err := NewtonRalphson(Expressao{Corpo: "x**2 - 2", Parametro: "x"}, Expressao{Corpo: "2*x"}, 1, criterio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if simples.Multiplicidade != 1 {
+		t.Errorf("raiz simples deveria ter multiplicidade 1, obteve %d", simples.Multiplicidade)
+	}
+}
+
+func TestOrdemConvergencia(t *testing.T) {
+	funcao := Expressao{Corpo: "x**3 - 2*x - 5", Parametro: "x", A: 2, B: 3}
+	criterio := NewCriterioParada(12)
+
+	bisseccao, err := Bisseccao(funcao, criterio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secante, err := Secante(funcao, 2, 3, criterio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newton, err := NewtonRalphson(funcao, Expressao{Corpo: "3*x**2 - 2"}, 3, criterio)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []struct {
+		nome       string
+		resultado  Resultado
+		ordem      float64
+		tolerancia float64
+	}{
+		{"bissecção", bisseccao, 1, 0.01},
+		{"secante", secante, 1.618, 0.2},
+		{"Newton", newton, 2, 0.2},
+	}
+	for _, c := range casos {
+		if c.resultado.Ordem == nil {
+			t.Errorf("%s: ordem de convergência não estimada", c.nome)
+			continue
+		}
+		if math.Abs(c.resultado.Ordem.Ordem-c.ordem) > c.tolerancia {
+			t.Errorf("%s: ordem esperada ≈ %v, obtida %v", c.nome, c.ordem, c.resultado.Ordem.Ordem)
+		}
+	}
+	if math.Abs(bisseccao.Ordem.Constante-0.5) > 0.01 {
+		t.Errorf("a bissecção deveria ter constante 0.5, obteve %v", bisseccao.Ordem.Constante)
+	}
+
+	ordem, err := EstimarOrdem(newton.Historico)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(ordem.Ordem-2) > 0.2 {
+		t.Errorf("EstimarOrdem: ordem esperada ≈ 2, obtida %v", ordem.Ordem)
+	}
+	if _, err := EstimarOrdem([]float64{1, 2}); err == nil {
+		t.Error("EstimarOrdem deveria recusar históricos curtos")
+	}
+}